@@ -1,20 +1,27 @@
 module github.com/imouto1994/yume
 
-go 1.16
+go 1.20
 
 require (
 	github.com/go-chi/chi/v5 v5.0.3
 	github.com/go-chi/cors v1.2.0
-	github.com/go-playground/universal-translator v0.17.0 // indirect
 	github.com/go-playground/validator v9.31.0+incompatible
 	github.com/golang-migrate/migrate/v4 v4.14.2-0.20210706174543-31cedbb9613a
 	github.com/jmoiron/sqlx v1.3.4
-	github.com/leodido/go-urn v1.2.1 // indirect
 	github.com/mattn/go-sqlite3 v1.14.7
-	go.uber.org/atomic v1.8.0 // indirect
-	go.uber.org/multierr v1.7.0 // indirect
+	github.com/robfig/cron/v3 v3.0.1
 	go.uber.org/zap v1.17.0
 	golang.org/x/image v0.0.0-20210628002857-a66eb6448b8d
-	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
+
+require (
+	github.com/go-playground/locales v0.13.0 // indirect
+	github.com/go-playground/universal-translator v0.17.0 // indirect
+	github.com/hashicorp/errwrap v1.0.0 // indirect
+	github.com/hashicorp/go-multierror v1.1.0 // indirect
+	github.com/leodido/go-urn v1.2.1 // indirect
+	go.uber.org/atomic v1.8.0 // indirect
+	go.uber.org/multierr v1.7.0 // indirect
+	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
+)
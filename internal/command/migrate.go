@@ -0,0 +1,107 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/imouto1994/yume/internal/infra/config"
+	"github.com/imouto1994/yume/internal/infra/migration"
+)
+
+const migrateUsage = `usage: yume migrate <action>
+
+actions:
+  status      show the version of the database and the known migrations
+  up          apply all pending migrations
+  down N      revert the last N applied migrations
+  goto V      apply or revert migrations until the database is at version V
+  force V     set the version of the database to V without running migrations,
+              after a failed migration was fixed by hand`
+
+// RunMigrate manages the migrations of the database, so that an upgrade can be rolled back
+// without deleting the database
+func RunMigrate(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), migrateUsage)
+	}
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("cMigrate - missing action")
+	}
+	action := flags.Arg(0)
+
+	// Every action besides status and up takes a single number
+	var number int
+	switch action {
+	case "status", "up":
+		if flags.NArg() != 1 {
+			flags.Usage()
+			return fmt.Errorf("cMigrate - action %s takes no arguments", action)
+		}
+	case "down", "goto", "force":
+		if flags.NArg() != 2 {
+			flags.Usage()
+			return fmt.Errorf("cMigrate - action %s takes a single number", action)
+		}
+		var err error
+		number, err = strconv.Atoi(flags.Arg(1))
+		if err != nil {
+			return fmt.Errorf("cMigrate - argument of action %s is not a number: %w", action, err)
+		}
+	default:
+		flags.Usage()
+		return fmt.Errorf("cMigrate - unknown action: %s", action)
+	}
+
+	mg, err := migration.Open(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("cMigrate - failed to open migrations: %w", err)
+	}
+	defer mg.Close()
+
+	switch action {
+	case "up":
+		err = mg.Up()
+	case "down":
+		err = mg.Down(number)
+	case "goto":
+		if number < 1 {
+			return fmt.Errorf("cMigrate - version to migrate to must be positive, use down to revert all migrations: %d", number)
+		}
+		err = mg.Goto(uint(number))
+	case "force":
+		err = mg.Force(number)
+	}
+	if err != nil {
+		return fmt.Errorf("cMigrate - failed to run action %s: %w", action, err)
+	}
+
+	status, err := mg.Status()
+	if err != nil {
+		return fmt.Errorf("cMigrate - failed to get migration status: %w", err)
+	}
+	printMigrationStatus(status)
+
+	return nil
+}
+
+func printMigrationStatus(status *migration.Status) {
+	fmt.Printf("version: %d (latest %d)\n", status.Version, status.Latest())
+	if status.Dirty {
+		fmt.Printf("dirty: migration %d failed halfway, fix the database and use force to set its version\n", status.Version)
+	}
+
+	for _, m := range status.Migrations {
+		state := "pending"
+		if m.Version < status.Version || (m.Version == status.Version && !status.Dirty) {
+			state = "applied"
+		} else if m.Version == status.Version {
+			state = "dirty"
+		}
+		fmt.Printf("  %-8s %d_%s\n", state, m.Version, m.Name)
+	}
+}
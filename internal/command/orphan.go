@@ -0,0 +1,77 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/imouto1994/yume/internal/infra/config"
+	"github.com/imouto1994/yume/internal/infra/migration"
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/imouto1994/yume/internal/repository"
+	"github.com/imouto1994/yume/internal/service"
+)
+
+// RunOrphans reports the rows left behind by deletes made before foreign keys were enforced,
+// and deletes them when the flag -delete is given
+func RunOrphans(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("orphans", flag.ExitOnError)
+	remove := flags.Bool("delete", false, "delete orphaned rows instead of only reporting them")
+	flags.Parse(args)
+
+	// Schema is only migrated when the server would migrate it as well
+	if *cfg.Database.AutoMigrate {
+		migration.UpLatest(cfg.Database)
+	} else {
+		migration.EnsureLatest(cfg.Database)
+	}
+
+	db, err := sqlite.Connect(cfg.Database)
+	if err != nil {
+		return fmt.Errorf("cOrphan - failed to establish connection to database: %w", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	serviceOrphan := service.NewServiceOrphan(repository.NewRepositoryOrphan())
+
+	if !*remove {
+		counts, err := serviceOrphan.FindOrphans(ctx, db)
+		if err != nil {
+			return fmt.Errorf("cOrphan - failed to use service Orphan to find orphaned rows: %w", err)
+		}
+		printOrphanCounts(counts, "found")
+		return nil
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("cOrphan - failed to begin SQL transaction for deleting orphaned rows: %w", err)
+	}
+
+	counts, err := serviceOrphan.RemoveOrphans(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("cOrphan - failed to use service Orphan to delete orphaned rows: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("cOrphan - failed to commit SQL transaction for deleting orphaned rows: %w", err)
+	}
+	printOrphanCounts(counts, "deleted")
+
+	return nil
+}
+
+func printOrphanCounts(counts []*model.OrphanCount, action string) {
+	if len(counts) == 0 {
+		fmt.Println("no orphaned rows")
+		return
+	}
+
+	for _, count := range counts {
+		fmt.Printf("%s: %d orphaned rows %s\n", count.Table, count.Count, action)
+	}
+}
@@ -0,0 +1,52 @@
+package command
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/imouto1994/yume/internal/infra/config"
+	"github.com/imouto1994/yume/internal/service"
+)
+
+const restoreUsage = `usage: yume restore <snapshot>
+
+Replaces the database with a snapshot, given as a path or as the name of a snapshot
+in the backup directory. The server must be stopped, the replaced database is kept
+next to it with the suffix .pre-restore. Without snapshot, the available ones are listed.`
+
+// RunRestore swaps in a snapshot of the database before the server is started
+func RunRestore(cfg *config.Config, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), restoreUsage)
+	}
+	flags.Parse(args)
+
+	serviceBackup := service.NewServiceBackup(cfg.Backup, cfg.Database)
+
+	if flags.NArg() == 0 {
+		backups, err := serviceBackup.GetBackups()
+		if err != nil {
+			return fmt.Errorf("cRestore - failed to use service Backup to get backups: %w", err)
+		}
+		flags.Usage()
+		fmt.Printf("\nsnapshots in %s:\n", cfg.Backup.Directory)
+		for _, backup := range backups {
+			fmt.Printf("  %s  %s  %d bytes\n", backup.Name, backup.CreatedAt, backup.Size)
+		}
+		return nil
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("cRestore - only a single snapshot can be restored")
+	}
+
+	err := serviceBackup.RestoreBackup(context.Background(), flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("cRestore - failed to use service Backup to restore snapshot: %w", err)
+	}
+	fmt.Printf("restored %s into %s\n", flags.Arg(0), cfg.Database.Path)
+
+	return nil
+}
@@ -3,12 +3,86 @@ package config
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"runtime"
 
+	"github.com/imouto1994/yume/internal/model"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	HTTPPort string `yaml:"http_port" validate:"required"`
+	HTTPPort    string                 `yaml:"http_port" validate:"required"`
+	ScanWorkers int                    `yaml:"scan_workers" validate:"min=0"`
+	Database    Database               `yaml:"database"`
+	Backup      Backup                 `yaml:"backup"`
+	Conventions map[string]*Convention `yaml:"conventions" validate:"dive"`
+}
+
+// Database describes where the SQLite database is stored and how its connections are tuned,
+// the same settings apply to connections for migrations and for serving.
+// CacheSize follows SQLite, a negative size is in KiB and a positive one in pages, zero keeps the SQLite default.
+// MaxOpenConns limits the connections of the pool, zero means no limit.
+// AutoMigrate applies pending migrations at startup, otherwise the server refuses to start until they are applied
+type Database struct {
+	Path          string `yaml:"path"`
+	JournalMode   string `yaml:"journal_mode" validate:"omitempty,oneof=delete truncate persist memory wal off DELETE TRUNCATE PERSIST MEMORY WAL OFF"`
+	BusyTimeoutMS int    `yaml:"busy_timeout_ms" validate:"min=0"`
+	Synchronous   string `yaml:"synchronous" validate:"omitempty,oneof=off normal full extra OFF NORMAL FULL EXTRA"`
+	CacheSize     int    `yaml:"cache_size"`
+	MaxOpenConns  int    `yaml:"max_open_conns" validate:"min=0"`
+	AutoMigrate   *bool  `yaml:"auto_migrate"`
+}
+
+// Backup describes where snapshots of the database are stored and how many of the latest ones are kept.
+// Schedule takes the same formats as scan schedules of libraries, no backups are taken on schedule when it is empty
+type Backup struct {
+	Directory string `yaml:"directory"`
+	Keep      int    `yaml:"keep" validate:"min=0"`
+	Schedule  string `yaml:"schedule"`
+}
+
+// DefaultBackup is used for the settings of backups which are not given
+var DefaultBackup = Backup{
+	Directory: "backups",
+	Keep:      7,
+}
+
+// DefaultDatabase is used for the settings of the database which are not given
+var DefaultDatabase = Database{
+	Path:          "sqlite3.db",
+	JournalMode:   "WAL",
+	BusyTimeoutMS: 5000,
+	Synchronous:   "NORMAL",
+}
+
+// Convention describes how title folders and book files are named in a library.
+// Patterns use named captures to extract fields from the names:
+// "created_at", "author" and "circle" for titles, "lang" and "flags" for books.
+// Flags are stored as tags, Flags maps tag names to the flag values standing for them.
+// Several authors or circles of a title are separated by NameSeparator, "," by default
+type Convention struct {
+	TitlePatterns []string            `yaml:"title_patterns" json:"title_patterns"`
+	BookPatterns  []string            `yaml:"book_patterns" json:"book_patterns"`
+	DateFormat    string              `yaml:"date_format" json:"date_format" validate:"required"`
+	DefaultLang   string              `yaml:"default_lang" json:"default_lang" validate:"required"`
+	FlagSeparator string              `yaml:"flag_separator" json:"flag_separator" validate:"required"`
+	NameSeparator string              `yaml:"name_separator" json:"name_separator"`
+	Flags         map[string][]string `yaml:"flags" json:"flags"`
+}
+
+// DefaultConvention is the naming used when a library does not specify any convention:
+// "[Author (Circle)] Title Name {2006-01-02}" for titles and "[EN] Book Name [Uncensored, Waifu2x]" for books
+var DefaultConvention = Convention{
+	TitlePatterns: []string{`.*\{(?P<created_at>.*)\}`, `^\[(?P<author>[^\]\(]+?)\s*(?:\((?P<circle>[^\)]*)\))?\]`},
+	BookPatterns:  []string{`^\[(?P<lang>[^\]]*)\]`, `^.+\[(?P<flags>[^\[]*)\]$`},
+	DateFormat:    "2006-01-02",
+	DefaultLang:   "jp",
+	FlagSeparator: ",",
+	NameSeparator: ",",
+	Flags: map[string][]string{
+		"uncensored": {"Uncensored", "Decensored"},
+		"waifu2x":    {"Waifu2x"},
+	},
 }
 
 type validate interface {
@@ -36,5 +110,55 @@ func Initialize(v validate) (*Config, error) {
 		return nil, fmt.Errorf("config file is not valid: %w", err)
 	}
 
+	// Scan with as many workers as CPUs by default
+	if config.ScanWorkers == 0 {
+		config.ScanWorkers = runtime.NumCPU()
+	}
+
+	// Database is stored next to the process unless another path is given
+	if config.Database.Path == "" {
+		config.Database.Path = DefaultDatabase.Path
+	}
+	if config.Database.JournalMode == "" {
+		config.Database.JournalMode = DefaultDatabase.JournalMode
+	}
+	if config.Database.BusyTimeoutMS == 0 {
+		config.Database.BusyTimeoutMS = DefaultDatabase.BusyTimeoutMS
+	}
+	if config.Database.Synchronous == "" {
+		config.Database.Synchronous = DefaultDatabase.Synchronous
+	}
+	if config.Database.AutoMigrate == nil {
+		autoMigrate := true
+		config.Database.AutoMigrate = &autoMigrate
+	}
+
+	// Backups are stored next to the process unless another directory is given
+	if config.Backup.Directory == "" {
+		config.Backup.Directory = DefaultBackup.Directory
+	}
+	if config.Backup.Keep == 0 {
+		config.Backup.Keep = DefaultBackup.Keep
+	}
+
+	// Default convention is always available unless it is overridden
+	if config.Conventions == nil {
+		config.Conventions = make(map[string]*Convention)
+	}
+	if _, ok := config.Conventions[model.DefaultConventionName]; !ok {
+		defaultConvention := DefaultConvention
+		config.Conventions[model.DefaultConventionName] = &defaultConvention
+	}
+	for name, convention := range config.Conventions {
+		if convention.NameSeparator == "" {
+			convention.NameSeparator = ","
+		}
+		for _, pattern := range append(append([]string{}, convention.TitlePatterns...), convention.BookPatterns...) {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("config file is not valid: pattern of convention %s does not compile: %w", name, err)
+			}
+		}
+	}
+
 	return &config, nil
 }
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// PrepareEventStream sets up the response for Server-Sent Events
+// and lifts the server write timeout for the long-lived connection
+func PrepareEventStream(writer http.ResponseWriter) (http.Flusher, error) {
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("response writer does not support flushing")
+	}
+
+	err := http.NewResponseController(writer).SetWriteDeadline(time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear write deadline: %w", err)
+	}
+
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	writer.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return flusher, nil
+}
+
+func WriteEvent(writer http.ResponseWriter, flusher http.Flusher, event string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode event data to JSON: %w", err)
+	}
+
+	_, err = fmt.Fprintf(writer, "event: %s\ndata: %s\n\n", event, b)
+	if err != nil {
+		return err
+	}
+	flusher.Flush()
+
+	return nil
+}
+
+func WriteEventComment(writer http.ResponseWriter, flusher http.Flusher, comment string) error {
+	_, err := fmt.Fprintf(writer, ": %s\n\n", comment)
+	if err != nil {
+		return err
+	}
+	flusher.Flush()
+
+	return nil
+}
@@ -45,6 +45,8 @@ func RespondInternalServerError(writer http.ResponseWriter, message string, err
 func RespondError(writer http.ResponseWriter, message string, err error) {
 	if errors.Is(err, model.ErrNotFound) {
 		RespondNotFoundError(writer, message, err)
+	} else if errors.Is(err, model.ErrBadRequest) {
+		RespondBadRequestError(writer, message, err)
 	} else {
 		RespondInternalServerError(writer, message, err)
 	}
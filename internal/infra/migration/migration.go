@@ -0,0 +1,213 @@
+package migration
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/imouto1994/yume/internal/infra/config"
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+)
+
+//go:embed sql/*.sql
+var fs embed.FS
+
+// Migrator applies the embedded migrations to the database
+type Migrator struct {
+	m      *migrate.Migrate
+	source source.Driver
+}
+
+// Migration is an embedded migration, identified by its version
+type Migration struct {
+	Version uint
+	Name    string
+}
+
+// Status tells which version the database is at and which migrations are known,
+// Version is 0 when no migration was applied yet and Dirty when the last migration failed halfway
+type Status struct {
+	Version    uint
+	Dirty      bool
+	Migrations []*Migration
+}
+
+// Latest gives the version of the last known migration
+func (s *Status) Latest() uint {
+	if len(s.Migrations) == 0 {
+		return 0
+	}
+
+	return s.Migrations[len(s.Migrations)-1].Version
+}
+
+func Open(cfg config.Database) (*Migrator, error) {
+	err := sqlite.CreateDirectory(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Foreign keys stay disabled while migrating, since tables are dropped and created again to change their constraints
+	db, err := sql.Open("sqlite3", sqlite.DataSourceName(cfg, false))
+	if err != nil {
+		return nil, fmt.Errorf("failed to establish connection to database: %w", err)
+	}
+	err = sqlite.CheckFTS5(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create SQLite3 instance: %w", err)
+	}
+
+	fileSource, err := iofs.New(fs, "sql")
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open source file: %w", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", fileSource, cfg.Path, driver)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+
+	return &Migrator{
+		m:      m,
+		source: fileSource,
+	}, nil
+}
+
+func (mg *Migrator) Close() error {
+	sourceErr, databaseErr := mg.m.Close()
+	if sourceErr != nil {
+		return sourceErr
+	}
+
+	return databaseErr
+}
+
+// Up applies all migrations which are not applied yet
+func (mg *Migrator) Up() error {
+	err := mg.m.Up()
+	if err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("failed to migrate up: %w", err)
+	}
+
+	return nil
+}
+
+// Down reverts the given number of last applied migrations
+func (mg *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("number of migrations to revert must be positive: %d", steps)
+	}
+
+	err := mg.m.Steps(-steps)
+	if err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("failed to migrate down: %w", err)
+	}
+
+	return nil
+}
+
+// Goto applies or reverts migrations until the database is at the given version
+func (mg *Migrator) Goto(version uint) error {
+	err := mg.m.Migrate(version)
+	if err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("failed to migrate to version %d: %w", version, err)
+	}
+
+	return nil
+}
+
+// Force sets the version of the database without running any migration and clears its dirty state,
+// it is meant to recover after a migration failed halfway and the database was fixed by hand
+func (mg *Migrator) Force(version int) error {
+	err := mg.m.Force(version)
+	if err != nil {
+		return fmt.Errorf("failed to force version %d: %w", version, err)
+	}
+
+	return nil
+}
+
+func (mg *Migrator) Status() (*Status, error) {
+	status := &Status{
+		Migrations: []*Migration{},
+	}
+
+	version, dirty, err := mg.m.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return nil, fmt.Errorf("failed to get version of database: %w", err)
+	}
+	status.Version = version
+	status.Dirty = dirty
+
+	version, err = mg.source.First()
+	for err == nil {
+		reader, name, readErr := mg.source.ReadUp(version)
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read migration %d: %w", version, readErr)
+		}
+		reader.Close()
+		status.Migrations = append(status.Migrations, &Migration{
+			Version: version,
+			Name:    name,
+		})
+
+		version, err = mg.source.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	return status, nil
+}
+
+// UpLatest applies all pending migrations, the process exits when the database cannot be migrated
+func UpLatest(cfg config.Database) {
+	mg, err := Open(cfg)
+	if err != nil {
+		zap.L().Fatal("failed to prepare migration", zap.Error(err))
+	}
+	defer mg.Close()
+
+	err = mg.Up()
+	if err != nil {
+		zap.L().Fatal("failed to migrate up", zap.Error(err))
+	}
+
+	zap.L().Info("migrate up to latest successfully!")
+}
+
+// EnsureLatest checks that all migrations are applied without applying any,
+// the process exits when the database is not at the latest version
+func EnsureLatest(cfg config.Database) {
+	mg, err := Open(cfg)
+	if err != nil {
+		zap.L().Fatal("failed to prepare migration", zap.Error(err))
+	}
+	defer mg.Close()
+
+	status, err := mg.Status()
+	if err != nil {
+		zap.L().Fatal("failed to get migration status", zap.Error(err))
+	}
+	if status.Dirty || status.Version != status.Latest() {
+		zap.L().Fatal("database is not at the latest version, run the migrate command first",
+			zap.Uint("version", status.Version), zap.Bool("dirty", status.Dirty), zap.Uint("latest", status.Latest()))
+	}
+}
@@ -0,0 +1 @@
+DROP TABLE LIBRARY_SCAN;
@@ -0,0 +1,18 @@
+CREATE TABLE LIBRARY_SCAN (
+  LIBRARY_ID INTEGER PRIMARY KEY,
+  STATUS TEXT NOT NULL,
+  STARTED_AT TEXT NOT NULL,
+  ENDED_AT TEXT NOT NULL,
+  DURATION_MS INTEGER NOT NULL,
+  TITLES_ADDED INTEGER NOT NULL,
+  TITLES_REMOVED INTEGER NOT NULL,
+  TITLES_UPDATED INTEGER NOT NULL,
+  BOOKS_ADDED INTEGER NOT NULL,
+  BOOKS_REMOVED INTEGER NOT NULL,
+  BOOKS_UPDATED INTEGER NOT NULL,
+  PAGES_ADDED INTEGER NOT NULL,
+  PAGES_REMOVED INTEGER NOT NULL,
+  PAGES_UPDATED INTEGER NOT NULL,
+  ERROR_COUNT INTEGER NOT NULL,
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID)
+);
@@ -0,0 +1 @@
+DROP TABLE TITLE_SEARCH;
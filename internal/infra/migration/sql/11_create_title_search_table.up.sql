@@ -0,0 +1,18 @@
+CREATE VIRTUAL TABLE TITLE_SEARCH USING fts5 (
+  NAME,
+  BOOK_NAMES,
+  METADATA,
+  LIBRARY_ID UNINDEXED,
+  tokenize = 'unicode61 remove_diacritics 2',
+  prefix = '2 3'
+);
+INSERT INTO TITLE_SEARCH (TITLE_SEARCH, rank) VALUES ('rank', 'bm25(10.0, 2.0, 1.0)');
+
+INSERT INTO TITLE_SEARCH (rowid, NAME, BOOK_NAMES, METADATA, LIBRARY_ID)
+SELECT
+  TITLE.ID,
+  TITLE.NAME,
+  COALESCE((SELECT group_concat(BOOK.NAME, ' ') FROM BOOK WHERE BOOK.TITLE_ID = TITLE.ID), ''),
+  TITLE.GROUP_PATH || ' ' || TITLE.LANGS || ' ' || COALESCE((SELECT group_concat(TAG.NAME, ' ') FROM TITLE_TAG JOIN TAG ON TAG.ID = TITLE_TAG.TAG_ID WHERE TITLE_TAG.TITLE_ID = TITLE.ID), ''),
+  TITLE.LIBRARY_ID
+FROM TITLE;
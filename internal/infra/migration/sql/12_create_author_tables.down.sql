@@ -0,0 +1,4 @@
+DROP TABLE TITLE_CIRCLE;
+DROP TABLE CIRCLE;
+DROP TABLE TITLE_AUTHOR;
+DROP TABLE AUTHOR;
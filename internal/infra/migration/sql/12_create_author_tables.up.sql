@@ -0,0 +1,33 @@
+CREATE TABLE AUTHOR (
+  ID INTEGER PRIMARY KEY,
+  NAME TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE TITLE_AUTHOR (
+  TITLE_ID INTEGER NOT NULL,
+  AUTHOR_ID INTEGER NOT NULL,
+  LIBRARY_ID INTEGER NOT NULL,
+  PRIMARY KEY (TITLE_ID, AUTHOR_ID),
+  FOREIGN KEY (TITLE_ID) REFERENCES TITLE (ID),
+  FOREIGN KEY (AUTHOR_ID) REFERENCES AUTHOR (ID),
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID)
+);
+CREATE INDEX idx__title_author__author_id on TITLE_AUTHOR (AUTHOR_ID);
+CREATE INDEX idx__title_author__library_id on TITLE_AUTHOR (LIBRARY_ID);
+
+CREATE TABLE CIRCLE (
+  ID INTEGER PRIMARY KEY,
+  NAME TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE TITLE_CIRCLE (
+  TITLE_ID INTEGER NOT NULL,
+  CIRCLE_ID INTEGER NOT NULL,
+  LIBRARY_ID INTEGER NOT NULL,
+  PRIMARY KEY (TITLE_ID, CIRCLE_ID),
+  FOREIGN KEY (TITLE_ID) REFERENCES TITLE (ID),
+  FOREIGN KEY (CIRCLE_ID) REFERENCES CIRCLE (ID),
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID)
+);
+CREATE INDEX idx__title_circle__circle_id on TITLE_CIRCLE (CIRCLE_ID);
+CREATE INDEX idx__title_circle__library_id on TITLE_CIRCLE (LIBRARY_ID);
@@ -0,0 +1 @@
+ALTER TABLE TITLE DROP COLUMN READ_AT;
@@ -0,0 +1 @@
+ALTER TABLE TITLE ADD COLUMN READ_AT TEXT;
@@ -0,0 +1,194 @@
+-- Tables with references are created again without cascading deletes, as they were before.
+-- Migrations run with foreign keys disabled so that dropping the old tables does not delete any rows.
+
+CREATE TABLE TITLE_NEW (
+  ID INTEGER PRIMARY KEY,
+  NAME TEXT NOT NULL,
+  URL TEXT NOT NULL,
+  CREATED_AT TEXT NOT NULL,
+  UPDATED_AT TEXT NOT NULL,
+  COVER_WIDTH INTEGER NOT NULL,
+  COVER_HEIGHT INTEGER NOT NULL,
+  BOOK_COUNT INTEGER NOT NULL,
+  LANGS TEXT NOT NULL,
+  LIBRARY_ID INTEGER NOT NULL,
+  COVER_HASH TEXT,
+  GROUP_PATH TEXT NOT NULL DEFAULT '',
+  READ_AT TEXT,
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID)
+);
+INSERT INTO TITLE_NEW (ID, NAME, URL, CREATED_AT, UPDATED_AT, COVER_WIDTH, COVER_HEIGHT, BOOK_COUNT, LANGS, LIBRARY_ID, COVER_HASH, GROUP_PATH, READ_AT)
+  SELECT ID, NAME, URL, CREATED_AT, UPDATED_AT, COVER_WIDTH, COVER_HEIGHT, BOOK_COUNT, LANGS, LIBRARY_ID, COVER_HASH, GROUP_PATH, READ_AT FROM TITLE;
+DROP TABLE TITLE;
+ALTER TABLE TITLE_NEW RENAME TO TITLE;
+CREATE INDEX idx__title__library_id on TITLE (LIBRARY_ID);
+
+CREATE TABLE BOOK_NEW (
+  ID INTEGER PRIMARY KEY,
+  NAME TEXT NOT NULL,
+  URL TEXT NOT NULL,
+  CREATED_AT TEXT NOT NULL,
+  UPDATED_AT TEXT NOT NULL,
+  PREVIEW_URL TEXT,
+  PREVIEW_UPDATED_AT TEXT,
+  PAGE_COUNT INTEGER NOT NULL,
+  TITLE_ID INTEGER NOT NULL,
+  LIBRARY_ID INTEGER NOT NULL,
+  FINGERPRINT TEXT,
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID),
+  FOREIGN KEY (TITLE_ID) REFERENCES TITLE (ID)
+);
+INSERT INTO BOOK_NEW (ID, NAME, URL, CREATED_AT, UPDATED_AT, PREVIEW_URL, PREVIEW_UPDATED_AT, PAGE_COUNT, TITLE_ID, LIBRARY_ID, FINGERPRINT)
+  SELECT ID, NAME, URL, CREATED_AT, UPDATED_AT, PREVIEW_URL, PREVIEW_UPDATED_AT, PAGE_COUNT, TITLE_ID, LIBRARY_ID, FINGERPRINT FROM BOOK;
+DROP TABLE BOOK;
+ALTER TABLE BOOK_NEW RENAME TO BOOK;
+CREATE INDEX idx__book__title_id on BOOK (TITLE_ID);
+CREATE INDEX idx__book__library_id on BOOK (LIBRARY_ID);
+CREATE INDEX idx__book__fingerprint on BOOK (FINGERPRINT);
+
+CREATE TABLE PAGE_NEW (
+  NUMBER INTEGER NOT NULL,
+  FILE_INDEX INTEGER NOT NULL,
+  WIDTH INTEGER NOT NULL,
+  HEIGHT INTEGER NOT NULL,
+  FAVORITE INTEGER NOT NULL,
+  BOOK_ID INTEGER NOT NULL,
+  TITLE_ID INTEGER NOT NULL,
+  LIBRARY_ID INTEGER NOT NULL,
+  PRIMARY KEY (BOOK_ID, NUMBER),
+  FOREIGN KEY (BOOK_ID) REFERENCES BOOK (ID),
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID),
+  FOREIGN KEY (TITLE_ID) REFERENCES TITLE (ID)
+);
+INSERT INTO PAGE_NEW (NUMBER, FILE_INDEX, WIDTH, HEIGHT, FAVORITE, BOOK_ID, TITLE_ID, LIBRARY_ID)
+  SELECT NUMBER, FILE_INDEX, WIDTH, HEIGHT, FAVORITE, BOOK_ID, TITLE_ID, LIBRARY_ID FROM PAGE;
+DROP TABLE PAGE;
+ALTER TABLE PAGE_NEW RENAME TO PAGE;
+CREATE INDEX idx__page__book_id on PAGE (BOOK_ID);
+CREATE INDEX idx__page__title_id on PAGE (TITLE_ID);
+CREATE INDEX idx__page__library_id on PAGE (LIBRARY_ID);
+
+CREATE TABLE PREVIEW_NEW (
+  NUMBER INTEGER NOT NULL,
+  FILE_INDEX INTEGER NOT NULL,
+  BOOK_ID INTEGER NOT NULL,
+  TITLE_ID INTEGER NOT NULL,
+  LIBRARY_ID INTEGER NOT NULL,
+  PRIMARY KEY (BOOK_ID, NUMBER),
+  FOREIGN KEY (BOOK_ID) REFERENCES BOOK (ID),
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID),
+  FOREIGN KEY (TITLE_ID) REFERENCES TITLE (ID)
+);
+INSERT INTO PREVIEW_NEW (NUMBER, FILE_INDEX, BOOK_ID, TITLE_ID, LIBRARY_ID)
+  SELECT NUMBER, FILE_INDEX, BOOK_ID, TITLE_ID, LIBRARY_ID FROM PREVIEW;
+DROP TABLE PREVIEW;
+ALTER TABLE PREVIEW_NEW RENAME TO PREVIEW;
+CREATE INDEX idx__preview__book_id on PREVIEW (BOOK_ID);
+CREATE INDEX idx__preview__title_id on PREVIEW (TITLE_ID);
+CREATE INDEX idx__preview__library_id on PREVIEW (LIBRARY_ID);
+
+CREATE TABLE SCAN_ERROR_NEW (
+  ID INTEGER PRIMARY KEY,
+  PATH TEXT NOT NULL,
+  TITLE_URL TEXT NOT NULL,
+  STAGE TEXT NOT NULL,
+  MESSAGE TEXT NOT NULL,
+  CREATED_AT TEXT NOT NULL,
+  LIBRARY_ID INTEGER NOT NULL,
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID)
+);
+INSERT INTO SCAN_ERROR_NEW (ID, PATH, TITLE_URL, STAGE, MESSAGE, CREATED_AT, LIBRARY_ID)
+  SELECT ID, PATH, TITLE_URL, STAGE, MESSAGE, CREATED_AT, LIBRARY_ID FROM SCAN_ERROR;
+DROP TABLE SCAN_ERROR;
+ALTER TABLE SCAN_ERROR_NEW RENAME TO SCAN_ERROR;
+CREATE INDEX idx__scan_error__library_id on SCAN_ERROR (LIBRARY_ID);
+CREATE INDEX idx__scan_error__title_url on SCAN_ERROR (TITLE_URL);
+
+CREATE TABLE LIBRARY_SCAN_NEW (
+  LIBRARY_ID INTEGER PRIMARY KEY,
+  STATUS TEXT NOT NULL,
+  STARTED_AT TEXT NOT NULL,
+  ENDED_AT TEXT NOT NULL,
+  DURATION_MS INTEGER NOT NULL,
+  TITLES_ADDED INTEGER NOT NULL,
+  TITLES_REMOVED INTEGER NOT NULL,
+  TITLES_UPDATED INTEGER NOT NULL,
+  BOOKS_ADDED INTEGER NOT NULL,
+  BOOKS_REMOVED INTEGER NOT NULL,
+  BOOKS_UPDATED INTEGER NOT NULL,
+  PAGES_ADDED INTEGER NOT NULL,
+  PAGES_REMOVED INTEGER NOT NULL,
+  PAGES_UPDATED INTEGER NOT NULL,
+  ERROR_COUNT INTEGER NOT NULL,
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID)
+);
+INSERT INTO LIBRARY_SCAN_NEW SELECT * FROM LIBRARY_SCAN;
+DROP TABLE LIBRARY_SCAN;
+ALTER TABLE LIBRARY_SCAN_NEW RENAME TO LIBRARY_SCAN;
+
+CREATE TABLE TITLE_TAG_NEW (
+  TITLE_ID INTEGER NOT NULL,
+  TAG_ID INTEGER NOT NULL,
+  LIBRARY_ID INTEGER NOT NULL,
+  PRIMARY KEY (TITLE_ID, TAG_ID),
+  FOREIGN KEY (TITLE_ID) REFERENCES TITLE (ID),
+  FOREIGN KEY (TAG_ID) REFERENCES TAG (ID),
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID)
+);
+INSERT INTO TITLE_TAG_NEW (TITLE_ID, TAG_ID, LIBRARY_ID)
+  SELECT TITLE_ID, TAG_ID, LIBRARY_ID FROM TITLE_TAG;
+DROP TABLE TITLE_TAG;
+ALTER TABLE TITLE_TAG_NEW RENAME TO TITLE_TAG;
+CREATE INDEX idx__title_tag__tag_id on TITLE_TAG (TAG_ID);
+CREATE INDEX idx__title_tag__library_id on TITLE_TAG (LIBRARY_ID);
+
+CREATE TABLE BOOK_TAG_NEW (
+  BOOK_ID INTEGER NOT NULL,
+  TAG_ID INTEGER NOT NULL,
+  TITLE_ID INTEGER NOT NULL,
+  LIBRARY_ID INTEGER NOT NULL,
+  PRIMARY KEY (BOOK_ID, TAG_ID),
+  FOREIGN KEY (BOOK_ID) REFERENCES BOOK (ID),
+  FOREIGN KEY (TAG_ID) REFERENCES TAG (ID),
+  FOREIGN KEY (TITLE_ID) REFERENCES TITLE (ID),
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID)
+);
+INSERT INTO BOOK_TAG_NEW (BOOK_ID, TAG_ID, TITLE_ID, LIBRARY_ID)
+  SELECT BOOK_ID, TAG_ID, TITLE_ID, LIBRARY_ID FROM BOOK_TAG;
+DROP TABLE BOOK_TAG;
+ALTER TABLE BOOK_TAG_NEW RENAME TO BOOK_TAG;
+CREATE INDEX idx__book_tag__tag_id on BOOK_TAG (TAG_ID);
+CREATE INDEX idx__book_tag__title_id on BOOK_TAG (TITLE_ID);
+CREATE INDEX idx__book_tag__library_id on BOOK_TAG (LIBRARY_ID);
+
+CREATE TABLE TITLE_AUTHOR_NEW (
+  TITLE_ID INTEGER NOT NULL,
+  AUTHOR_ID INTEGER NOT NULL,
+  LIBRARY_ID INTEGER NOT NULL,
+  PRIMARY KEY (TITLE_ID, AUTHOR_ID),
+  FOREIGN KEY (TITLE_ID) REFERENCES TITLE (ID),
+  FOREIGN KEY (AUTHOR_ID) REFERENCES AUTHOR (ID),
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID)
+);
+INSERT INTO TITLE_AUTHOR_NEW (TITLE_ID, AUTHOR_ID, LIBRARY_ID)
+  SELECT TITLE_ID, AUTHOR_ID, LIBRARY_ID FROM TITLE_AUTHOR;
+DROP TABLE TITLE_AUTHOR;
+ALTER TABLE TITLE_AUTHOR_NEW RENAME TO TITLE_AUTHOR;
+CREATE INDEX idx__title_author__author_id on TITLE_AUTHOR (AUTHOR_ID);
+CREATE INDEX idx__title_author__library_id on TITLE_AUTHOR (LIBRARY_ID);
+
+CREATE TABLE TITLE_CIRCLE_NEW (
+  TITLE_ID INTEGER NOT NULL,
+  CIRCLE_ID INTEGER NOT NULL,
+  LIBRARY_ID INTEGER NOT NULL,
+  PRIMARY KEY (TITLE_ID, CIRCLE_ID),
+  FOREIGN KEY (TITLE_ID) REFERENCES TITLE (ID),
+  FOREIGN KEY (CIRCLE_ID) REFERENCES CIRCLE (ID),
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID)
+);
+INSERT INTO TITLE_CIRCLE_NEW (TITLE_ID, CIRCLE_ID, LIBRARY_ID)
+  SELECT TITLE_ID, CIRCLE_ID, LIBRARY_ID FROM TITLE_CIRCLE;
+DROP TABLE TITLE_CIRCLE;
+ALTER TABLE TITLE_CIRCLE_NEW RENAME TO TITLE_CIRCLE;
+CREATE INDEX idx__title_circle__circle_id on TITLE_CIRCLE (CIRCLE_ID);
+CREATE INDEX idx__title_circle__library_id on TITLE_CIRCLE (LIBRARY_ID);
@@ -0,0 +1,2 @@
+ALTER TABLE BOOK DROP COLUMN FAVORITES_UPDATED_AT;
+ALTER TABLE PAGE DROP COLUMN FILE_NAME;
@@ -0,0 +1,2 @@
+ALTER TABLE PAGE ADD COLUMN FILE_NAME TEXT NOT NULL DEFAULT '';
+ALTER TABLE BOOK ADD COLUMN FAVORITES_UPDATED_AT TEXT;
@@ -0,0 +1,5 @@
+DROP TABLE PREVIEW;
+DROP TABLE PAGE;
+DROP TABLE BOOK;
+DROP TABLE TITLE;
+DROP TABLE LIBRARY;
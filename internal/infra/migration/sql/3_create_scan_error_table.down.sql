@@ -0,0 +1 @@
+DROP TABLE SCAN_ERROR;
@@ -0,0 +1,12 @@
+CREATE TABLE SCAN_ERROR (
+  ID INTEGER PRIMARY KEY,
+  PATH TEXT NOT NULL,
+  TITLE_URL TEXT NOT NULL,
+  STAGE TEXT NOT NULL,
+  MESSAGE TEXT NOT NULL,
+  CREATED_AT TEXT NOT NULL,
+  LIBRARY_ID INTEGER NOT NULL,
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID)
+);
+CREATE INDEX idx__scan_error__library_id on SCAN_ERROR (LIBRARY_ID);
+CREATE INDEX idx__scan_error__title_url on SCAN_ERROR (TITLE_URL);
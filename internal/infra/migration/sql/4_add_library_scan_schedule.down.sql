@@ -0,0 +1,3 @@
+ALTER TABLE LIBRARY DROP COLUMN SCAN_SCHEDULE;
+ALTER TABLE LIBRARY DROP COLUMN SCAN_LAST_RUN_AT;
+ALTER TABLE LIBRARY DROP COLUMN SCAN_NEXT_RUN_AT;
@@ -0,0 +1,3 @@
+ALTER TABLE LIBRARY ADD COLUMN SCAN_SCHEDULE TEXT;
+ALTER TABLE LIBRARY ADD COLUMN SCAN_LAST_RUN_AT TEXT;
+ALTER TABLE LIBRARY ADD COLUMN SCAN_NEXT_RUN_AT TEXT;
@@ -0,0 +1,3 @@
+DROP INDEX idx__book__fingerprint;
+ALTER TABLE BOOK DROP COLUMN FINGERPRINT;
+ALTER TABLE TITLE DROP COLUMN COVER_HASH;
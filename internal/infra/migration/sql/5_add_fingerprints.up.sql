@@ -0,0 +1,3 @@
+ALTER TABLE TITLE ADD COLUMN COVER_HASH TEXT;
+ALTER TABLE BOOK ADD COLUMN FINGERPRINT TEXT;
+CREATE INDEX idx__book__fingerprint on BOOK (FINGERPRINT);
@@ -0,0 +1,2 @@
+ALTER TABLE TITLE DROP COLUMN GROUP_PATH;
+ALTER TABLE LIBRARY DROP COLUMN SCAN_DEPTH;
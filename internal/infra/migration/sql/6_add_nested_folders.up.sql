@@ -0,0 +1,2 @@
+ALTER TABLE LIBRARY ADD COLUMN SCAN_DEPTH INTEGER;
+ALTER TABLE TITLE ADD COLUMN GROUP_PATH TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE LIBRARY DROP COLUMN CONVENTION;
@@ -0,0 +1 @@
+ALTER TABLE LIBRARY ADD COLUMN CONVENTION TEXT;
@@ -0,0 +1,10 @@
+ALTER TABLE TITLE ADD COLUMN UNCENSORED INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE TITLE ADD COLUMN WAIFU2X INTEGER NOT NULL DEFAULT 0;
+UPDATE TITLE SET UNCENSORED = 1
+  WHERE ID IN (SELECT TITLE_TAG.TITLE_ID FROM TITLE_TAG JOIN TAG ON TAG.ID = TITLE_TAG.TAG_ID WHERE TAG.NAME = 'uncensored');
+UPDATE TITLE SET WAIFU2X = 1
+  WHERE ID IN (SELECT TITLE_TAG.TITLE_ID FROM TITLE_TAG JOIN TAG ON TAG.ID = TITLE_TAG.TAG_ID WHERE TAG.NAME = 'waifu2x');
+
+DROP TABLE BOOK_TAG;
+DROP TABLE TITLE_TAG;
+DROP TABLE TAG;
@@ -0,0 +1,41 @@
+CREATE TABLE TAG (
+  ID INTEGER PRIMARY KEY,
+  NAME TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE TITLE_TAG (
+  TITLE_ID INTEGER NOT NULL,
+  TAG_ID INTEGER NOT NULL,
+  LIBRARY_ID INTEGER NOT NULL,
+  PRIMARY KEY (TITLE_ID, TAG_ID),
+  FOREIGN KEY (TITLE_ID) REFERENCES TITLE (ID),
+  FOREIGN KEY (TAG_ID) REFERENCES TAG (ID),
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID)
+);
+CREATE INDEX idx__title_tag__tag_id on TITLE_TAG (TAG_ID);
+CREATE INDEX idx__title_tag__library_id on TITLE_TAG (LIBRARY_ID);
+
+CREATE TABLE BOOK_TAG (
+  BOOK_ID INTEGER NOT NULL,
+  TAG_ID INTEGER NOT NULL,
+  TITLE_ID INTEGER NOT NULL,
+  LIBRARY_ID INTEGER NOT NULL,
+  PRIMARY KEY (BOOK_ID, TAG_ID),
+  FOREIGN KEY (BOOK_ID) REFERENCES BOOK (ID),
+  FOREIGN KEY (TAG_ID) REFERENCES TAG (ID),
+  FOREIGN KEY (TITLE_ID) REFERENCES TITLE (ID),
+  FOREIGN KEY (LIBRARY_ID) REFERENCES LIBRARY (ID)
+);
+CREATE INDEX idx__book_tag__tag_id on BOOK_TAG (TAG_ID);
+CREATE INDEX idx__book_tag__title_id on BOOK_TAG (TITLE_ID);
+CREATE INDEX idx__book_tag__library_id on BOOK_TAG (LIBRARY_ID);
+
+INSERT INTO TAG (NAME) SELECT 'uncensored' WHERE EXISTS (SELECT 1 FROM TITLE WHERE UNCENSORED = 1);
+INSERT INTO TAG (NAME) SELECT 'waifu2x' WHERE EXISTS (SELECT 1 FROM TITLE WHERE WAIFU2X = 1);
+INSERT INTO TITLE_TAG (TITLE_ID, TAG_ID, LIBRARY_ID)
+  SELECT TITLE.ID, TAG.ID, TITLE.LIBRARY_ID FROM TITLE, TAG WHERE TAG.NAME = 'uncensored' AND TITLE.UNCENSORED = 1;
+INSERT INTO TITLE_TAG (TITLE_ID, TAG_ID, LIBRARY_ID)
+  SELECT TITLE.ID, TAG.ID, TITLE.LIBRARY_ID FROM TITLE, TAG WHERE TAG.NAME = 'waifu2x' AND TITLE.WAIFU2X = 1;
+
+ALTER TABLE TITLE DROP COLUMN UNCENSORED;
+ALTER TABLE TITLE DROP COLUMN WAIFU2X;
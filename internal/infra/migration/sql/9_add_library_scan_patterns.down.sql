@@ -0,0 +1,3 @@
+ALTER TABLE LIBRARY DROP COLUMN INCLUDE_PATTERNS;
+ALTER TABLE LIBRARY DROP COLUMN EXCLUDE_PATTERNS;
+ALTER TABLE LIBRARY DROP COLUMN INCLUDE_HIDDEN;
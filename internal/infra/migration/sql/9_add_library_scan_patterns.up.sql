@@ -0,0 +1,3 @@
+ALTER TABLE LIBRARY ADD COLUMN INCLUDE_PATTERNS TEXT;
+ALTER TABLE LIBRARY ADD COLUMN EXCLUDE_PATTERNS TEXT;
+ALTER TABLE LIBRARY ADD COLUMN INCLUDE_HIDDEN INTEGER;
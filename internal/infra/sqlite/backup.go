@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Backup copies the database into a new file at the given path with the online backup API of SQLite,
+// which gives a consistent snapshot while other connections keep reading and writing.
+// The copy is written next to the path first and only moved there once it is complete
+func Backup(ctx context.Context, db DB, path string) error {
+	tempPath := path + ".tmp"
+	os.Remove(tempPath)
+
+	err := backup(ctx, db, tempPath)
+	if err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	err = os.Rename(tempPath, path)
+	if err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to move database backup in place: %w", err)
+	}
+
+	return nil
+}
+
+func backup(ctx context.Context, db DB, path string) error {
+	destDB, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return fmt.Errorf("failed to open database backup: %w", err)
+	}
+	defer destDB.Close()
+
+	destConn, err := destDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database backup: %w", err)
+	}
+	defer destConn.Close()
+
+	srcConn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer srcConn.Close()
+
+	err = destConn.Raw(func(destDriverConn interface{}) error {
+		return srcConn.Raw(func(srcDriverConn interface{}) error {
+			destSQLiteConn, ok := destDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("connection to database backup is not a SQLite connection")
+			}
+			srcSQLiteConn, ok := srcDriverConn.(*sqlite3.SQLiteConn)
+			if !ok {
+				return fmt.Errorf("connection to database is not a SQLite connection")
+			}
+
+			b, err := destSQLiteConn.Backup("main", srcSQLiteConn, "main")
+			if err != nil {
+				return fmt.Errorf("failed to start database backup: %w", err)
+			}
+
+			// Copy all pages in a single step, so that the backup does not restart on writes made in between
+			_, err = b.Step(-1)
+			if err != nil {
+				b.Finish()
+				return fmt.Errorf("failed to copy pages of database: %w", err)
+			}
+
+			err = b.Finish()
+			if err != nil {
+				return fmt.Errorf("failed to finish database backup: %w", err)
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// Backups are kept as single files rather than in the journal mode of the database
+	_, err = destConn.ExecContext(ctx, "PRAGMA journal_mode = DELETE")
+	if err != nil {
+		return fmt.Errorf("failed to change journal mode of database backup: %w", err)
+	}
+
+	return nil
+}
+
+// CheckIntegrity verifies that the database file at the given path is not corrupted
+func CheckIntegrity(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("failed to find database file: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return fmt.Errorf("failed to open database file: %w", err)
+	}
+	defer db.Close()
+
+	var result string
+	err = db.QueryRowContext(ctx, "PRAGMA integrity_check").Scan(&result)
+	if err != nil {
+		return fmt.Errorf("failed to check integrity of database file: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("database file is corrupted: %s", result)
+	}
+
+	return nil
+}
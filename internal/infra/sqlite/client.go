@@ -3,7 +3,13 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
 
+	"github.com/imouto1994/yume/internal/infra/config"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -25,8 +31,67 @@ type DB interface {
 	DBOps
 	Close() error
 	BeginTxx(context.Context, *sql.TxOptions) (*sqlx.Tx, error)
+	Conn(context.Context) (*sql.Conn, error)
 }
 
-func Connect() (DB, error) {
-	return sqlx.Connect("sqlite3", "sqlite3.db")
+// Connect opens the database with foreign keys enforced on every connection,
+// so that deleting a row cascades to the rows referencing it
+func Connect(cfg config.Database) (DB, error) {
+	err := CreateDirectory(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sqlx.Connect("sqlite3", DataSourceName(cfg, true))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+
+	return db, nil
+}
+
+// DataSourceName gives the name to open the database with, the driver applies the settings in it
+// to every new connection of the pool
+func DataSourceName(cfg config.Database, foreignKeys bool) string {
+	params := url.Values{}
+	params.Set("_journal_mode", cfg.JournalMode)
+	params.Set("_busy_timeout", strconv.Itoa(cfg.BusyTimeoutMS))
+	params.Set("_synchronous", cfg.Synchronous)
+	if cfg.CacheSize != 0 {
+		params.Set("_cache_size", strconv.Itoa(cfg.CacheSize))
+	}
+	params.Set("_foreign_keys", "0")
+	if foreignKeys {
+		params.Set("_foreign_keys", "1")
+	}
+
+	return cfg.Path + "?" + params.Encode()
+}
+
+// CheckFTS5 fails when the SQLite library of the driver is built without the FTS5 module which title search needs,
+// the driver only includes it when the binary is built with the tag sqlite_fts5
+func CheckFTS5(db *sql.DB) error {
+	var enabled bool
+	err := db.QueryRow("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&enabled)
+	if err != nil {
+		return fmt.Errorf("failed to check SQLite compile options: %w", err)
+	}
+	if !enabled {
+		return fmt.Errorf("SQLite is built without the FTS5 module, build the app with -tags sqlite_fts5 or with make")
+	}
+
+	return nil
+}
+
+// CreateDirectory creates the directory which the database is stored in when it does not exist yet
+func CreateDirectory(cfg config.Database) error {
+	err := os.MkdirAll(filepath.Dir(cfg.Path), 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create directory of database: %w", err)
+	}
+
+	return nil
 }
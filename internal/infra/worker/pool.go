@@ -0,0 +1,107 @@
+package worker
+
+import (
+	"context"
+	"sync"
+)
+
+// Pool bounds the number of tasks running at the same time across all of its groups
+type Pool struct {
+	slots chan struct{}
+}
+
+func NewPool(size int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Pool{
+		slots: make(chan struct{}, size),
+	}
+}
+
+func (p *Pool) Size() int {
+	return cap(p.slots)
+}
+
+// Group runs tasks on a pool with an additional concurrency limit of its own.
+// The first failing task cancels the context of the group and its error is returned by Wait.
+type Group struct {
+	pool   *Pool
+	slots  chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	waitGroup sync.WaitGroup
+	errOnce   sync.Once
+	err       error
+}
+
+// NewGroup creates a group of tasks on the pool, a limit lower than 1 or higher
+// than the pool size falls back to the pool size
+func (p *Pool) NewGroup(ctx context.Context, limit int) (*Group, context.Context) {
+	if limit < 1 || limit > p.Size() {
+		limit = p.Size()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	return &Group{
+		pool:   p,
+		slots:  make(chan struct{}, limit),
+		ctx:    ctx,
+		cancel: cancel,
+	}, ctx
+}
+
+// Go blocks until both the group and the pool have a free slot, then runs the task.
+// Tasks submitted after the group context is done are skipped.
+func (g *Group) Go(task func(context.Context) error) {
+	select {
+	case g.slots <- struct{}{}:
+	case <-g.ctx.Done():
+		g.fail(g.ctx.Err())
+		return
+	}
+
+	select {
+	case g.pool.slots <- struct{}{}:
+	case <-g.ctx.Done():
+		<-g.slots
+		g.fail(g.ctx.Err())
+		return
+	}
+
+	g.waitGroup.Add(1)
+	go func() {
+		defer func() {
+			<-g.pool.slots
+			<-g.slots
+			g.waitGroup.Done()
+		}()
+
+		if err := task(g.ctx); err != nil {
+			g.fail(err)
+		}
+	}()
+}
+
+// Wait blocks until all submitted tasks are done and returns the first error
+func (g *Group) Wait() error {
+	g.waitGroup.Wait()
+	g.cancel()
+
+	return g.err
+}
+
+// Stop cancels the remaining tasks and waits for the running ones to return
+func (g *Group) Stop() {
+	g.cancel()
+	g.Wait()
+}
+
+func (g *Group) fail(err error) {
+	g.errOnce.Do(func() {
+		g.err = err
+		g.cancel()
+	})
+}
@@ -0,0 +1,22 @@
+package model
+
+type Author struct {
+	ID         int64  `json:"id" db:"ID"`
+	Name       string `json:"name" db:"NAME"`
+	TitleCount int    `json:"title_count" db:"TITLE_COUNT"`
+}
+
+type Circle struct {
+	ID   int64  `json:"id" db:"ID"`
+	Name string `json:"name" db:"NAME"`
+}
+
+type TitleAuthor struct {
+	TitleID int64  `db:"TITLE_ID"`
+	Name    string `db:"NAME"`
+}
+
+type TitleCircle struct {
+	TitleID int64  `db:"TITLE_ID"`
+	Name    string `db:"NAME"`
+}
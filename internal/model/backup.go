@@ -0,0 +1,8 @@
+package model
+
+// Backup is a snapshot of the database stored in the backup directory
+type Backup struct {
+	Name      string `json:"name"`
+	Size      int64  `json:"size"`
+	CreatedAt string `json:"created_at"`
+}
@@ -9,6 +9,13 @@ type Book struct {
 	PreviewURL       *string `json:"preview_url" db:"PREVIEW_URL"`
 	PreviewUpdatedAt *string `json:"preview_updated_at" db:"PREVIEW_UPDATED_AT"`
 	PageCount        int     `json:"page_count" db:"PAGE_COUNT"`
-	TitleID          int64   `json:"title_id" db:"TITLE_ID"`
-	LibraryID        int64   `json:"library_id" db:"LIBRARY_ID"`
+	Fingerprint      *string `json:"fingerprint" db:"FINGERPRINT"`
+	// FavoritesUpdatedAt is when the favorite pages of the book were last changed
+	FavoritesUpdatedAt *string  `json:"favorites_updated_at" db:"FAVORITES_UPDATED_AT"`
+	TitleID            int64    `json:"title_id" db:"TITLE_ID"`
+	LibraryID          int64    `json:"library_id" db:"LIBRARY_ID"`
+	Tags               []string `json:"tags" db:"-"`
+
+	// Probe holds the archive of the book as read during a library scan, until its title is scanned
+	Probe *BookProbe `json:"-" db:"-"`
 }
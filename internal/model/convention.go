@@ -0,0 +1,28 @@
+package model
+
+// DefaultConventionName is the name of the convention used by libraries which do not specify any
+const DefaultConventionName = "default"
+
+// TitleNameInfo holds the fields parsed from the folder name of a title
+type TitleNameInfo struct {
+	CreatedAt *string           `json:"created_at"`
+	Authors   []string          `json:"authors"`
+	Circles   []string          `json:"circles"`
+	Captures  map[string]string `json:"captures"`
+}
+
+// BookNameInfo holds the fields parsed from the file name of a book
+type BookNameInfo struct {
+	Lang     string            `json:"lang"`
+	Flags    []string          `json:"flags"`
+	Captures map[string]string `json:"captures"`
+}
+
+const (
+	TitleCaptureCreatedAt = "created_at"
+	TitleCaptureAuthor    = "author"
+	TitleCaptureCircle    = "circle"
+
+	BookCaptureLang  = "lang"
+	BookCaptureFlags = "flags"
+)
@@ -0,0 +1,55 @@
+package model
+
+// FilterNode is a node of the syntax tree parsed from a title filter,
+// it is one of FilterAnd, FilterOr, FilterNot or FilterTerm
+type FilterNode interface {
+	filterNode()
+}
+
+type FilterAnd struct {
+	Nodes []FilterNode
+}
+
+type FilterOr struct {
+	Nodes []FilterNode
+}
+
+type FilterNot struct {
+	Node FilterNode
+}
+
+// FilterTerm compares a field of titles with a value,
+// terms without field match the value against the search index
+type FilterTerm struct {
+	Field    string
+	Operator string
+	Value    string
+}
+
+func (*FilterAnd) filterNode()  {}
+func (*FilterOr) filterNode()   {}
+func (*FilterNot) filterNode()  {}
+func (*FilterTerm) filterNode() {}
+
+const (
+	FilterFieldText     = ""
+	FilterFieldLibrary  = "library"
+	FilterFieldName     = "name"
+	FilterFieldTag      = "tag"
+	FilterFieldAuthor   = "author"
+	FilterFieldCircle   = "circle"
+	FilterFieldAuthorID = "author_id"
+	FilterFieldLang     = "lang"
+	FilterFieldBooks    = "books"
+	FilterFieldPages    = "pages"
+	FilterFieldAdded    = "added"
+	FilterFieldUpdated  = "updated"
+
+	FilterOperatorEqual        = ":"
+	FilterOperatorGreater      = ">"
+	FilterOperatorGreaterEqual = ">="
+	FilterOperatorLess         = "<"
+	FilterOperatorLessEqual    = "<="
+
+	FilterDateFormat = "2006-01-02"
+)
@@ -1,7 +1,137 @@
 package model
 
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
 type Library struct {
-	ID   int64  `json:"id" db:"ID"`
-	Name string `json:"name" db:"NAME"`
-	Root string `json:"root" db:"ROOT"`
+	ID              int64    `json:"id" db:"ID"`
+	Name            string   `json:"name" db:"NAME"`
+	Root            string   `json:"root" db:"ROOT"`
+	ScanWorkers     *int     `json:"scan_workers" db:"SCAN_WORKERS"`
+	ScanDepth       *int     `json:"scan_depth" db:"SCAN_DEPTH"`
+	Convention      *string  `json:"convention" db:"CONVENTION"`
+	IncludePatterns Patterns `json:"include_patterns" db:"INCLUDE_PATTERNS"`
+	ExcludePatterns Patterns `json:"exclude_patterns" db:"EXCLUDE_PATTERNS"`
+	IncludeHidden   *bool    `json:"include_hidden" db:"INCLUDE_HIDDEN"`
+	ScanSchedule    *string  `json:"scan_schedule" db:"SCAN_SCHEDULE"`
+	ScanLastRunAt   *string  `json:"scan_last_run_at" db:"SCAN_LAST_RUN_AT"`
+	ScanNextRunAt   *string  `json:"scan_next_run_at" db:"SCAN_NEXT_RUN_AT"`
+}
+
+// LibraryDetail is a library along with the statistics of its last scan and its current size
+type LibraryDetail struct {
+	*Library
+	LastScan *LibraryScan  `json:"last_scan"`
+	Totals   LibraryTotals `json:"totals"`
+}
+
+// LibraryScan holds the statistics of the last scan of a library
+type LibraryScan struct {
+	LibraryID     int64  `json:"library_id" db:"LIBRARY_ID"`
+	Status        string `json:"status" db:"STATUS"`
+	StartedAt     string `json:"started_at" db:"STARTED_AT"`
+	EndedAt       string `json:"ended_at" db:"ENDED_AT"`
+	DurationMS    int64  `json:"duration_ms" db:"DURATION_MS"`
+	TitlesAdded   int    `json:"titles_added" db:"TITLES_ADDED"`
+	TitlesRemoved int    `json:"titles_removed" db:"TITLES_REMOVED"`
+	TitlesUpdated int    `json:"titles_updated" db:"TITLES_UPDATED"`
+	BooksAdded    int    `json:"books_added" db:"BOOKS_ADDED"`
+	BooksRemoved  int    `json:"books_removed" db:"BOOKS_REMOVED"`
+	BooksUpdated  int    `json:"books_updated" db:"BOOKS_UPDATED"`
+	PagesAdded    int    `json:"pages_added" db:"PAGES_ADDED"`
+	PagesRemoved  int    `json:"pages_removed" db:"PAGES_REMOVED"`
+	PagesUpdated  int    `json:"pages_updated" db:"PAGES_UPDATED"`
+	ErrorCount    int    `json:"error_count" db:"ERROR_COUNT"`
+}
+
+type LibraryTotals struct {
+	TitleCount int `json:"title_count" db:"TITLE_COUNT"`
+	BookCount  int `json:"book_count" db:"BOOK_COUNT"`
+	PageCount  int `json:"page_count" db:"PAGE_COUNT"`
+}
+
+// GetScanWorkers returns the concurrency limit for scanning the library,
+// 0 means the global limit applies
+func (l *Library) GetScanWorkers() int {
+	if l.ScanWorkers == nil {
+		return 0
+	}
+
+	return *l.ScanWorkers
+}
+
+// GetScanDepth returns how deep below the library root title folders are,
+// folders above them are groups of titles
+func (l *Library) GetScanDepth() int {
+	if l.ScanDepth == nil {
+		return 1
+	}
+
+	return *l.ScanDepth
+}
+
+// GetConvention returns the name of the convention used to parse the names of titles and books
+// in the library, the default convention is used when it is not specified
+func (l *Library) GetConvention() string {
+	if l.Convention == nil {
+		return DefaultConventionName
+	}
+
+	return *l.Convention
+}
+
+// GetIncludeHidden tells whether hidden files and folders in the library are scanned,
+// they are ignored by default
+func (l *Library) GetIncludeHidden() bool {
+	if l.IncludeHidden == nil {
+		return false
+	}
+
+	return *l.IncludeHidden
+}
+
+// GetScanSchedule returns the cron expression or interval of scheduled scans,
+// an empty string means the library is only scanned on demand
+func (l *Library) GetScanSchedule() string {
+	if l.ScanSchedule == nil {
+		return ""
+	}
+
+	return *l.ScanSchedule
+}
+
+// Patterns is a list of glob patterns, stored as a JSON array
+type Patterns []string
+
+func (p Patterns) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal([]string(p))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (p *Patterns) Scan(src interface{}) error {
+	var data []byte
+	switch value := src.(type) {
+	case nil:
+		*p = nil
+		return nil
+	case string:
+		data = []byte(value)
+	case []byte:
+		data = value
+	default:
+		return fmt.Errorf("unsupported type %T for patterns", src)
+	}
+
+	return json.Unmarshal(data, (*[]string)(p))
 }
@@ -0,0 +1,15 @@
+package model
+
+// ForeignKeyViolation is a row referencing a row which does not exist, as reported by SQLite
+type ForeignKeyViolation struct {
+	Table  string `db:"table"`
+	RowID  *int64 `db:"rowid"`
+	Parent string `db:"parent"`
+	FKID   int    `db:"fkid"`
+}
+
+// OrphanCount is the number of orphaned rows found in a table
+type OrphanCount struct {
+	Table string `json:"table"`
+	Count int    `json:"count"`
+}
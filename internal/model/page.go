@@ -9,4 +9,17 @@ type Page struct {
 	BookID    int64 `json:"book_id" db:"BOOK_ID"`
 	TitleID   int64 `json:"title_id" db:"TITLE_ID"`
 	LibraryID int64 `json:"library_id" db:"LIBRARY_ID"`
+	// FileName is the name of the page file in the book archive, it is empty for pages scanned before it was stored
+	FileName string `json:"file_name" db:"FILE_NAME"`
+}
+
+// FavoritePage is a favorite page along with the location of its book, as listed when exporting user data
+type FavoritePage struct {
+	Index     int    `db:"FILE_INDEX"`
+	Number    int    `db:"NUMBER"`
+	FileName  string `db:"FILE_NAME"`
+	BookID    int64  `db:"BOOK_ID"`
+	BookURL   string `db:"BOOK_URL"`
+	TitleID   int64  `db:"TITLE_ID"`
+	LibraryID int64  `db:"LIBRARY_ID"`
 }
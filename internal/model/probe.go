@@ -0,0 +1,19 @@
+package model
+
+// BookProbe describes the archive of a book, read in a single pass over it
+type BookProbe struct {
+	Fingerprint string
+	Pages       []*ArchiveEntry
+
+	// HasDimensions tells whether dimensions of pages were read,
+	// they are only needed when the content of the book changed and its pages are scanned again
+	HasDimensions bool
+}
+
+// ArchiveEntry is a file in an archive, entries of a probe are in reading order
+type ArchiveEntry struct {
+	Index  int
+	Name   string
+	Width  int
+	Height int
+}
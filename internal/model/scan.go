@@ -1,6 +1,133 @@
 package model
 
+// ScanOptions tells how the files of a library are scanned
+type ScanOptions struct {
+	// DryRun scans without publishing progress, as nothing is applied to DB
+	DryRun bool
+	// KnownBookByURL holds the books in DB, archives of books not modified since are not probed again
+	KnownBookByURL map[string]*Book
+}
+
 type ScanResult struct {
-	TitleByTitleName map[string]*Title
-	BooksByTitleName map[string][]*Book
+	TitleByTitleURL  map[string]*Title
+	BooksByTitleURL  map[string][]*Book
+	ErrorsByTitleURL map[string][]*ScanError
+}
+
+const (
+	ScanStageStarted   = "started"
+	ScanStageCovers    = "covers"
+	ScanStageFolders   = "folders"
+	ScanStageTitles    = "titles"
+	ScanStageBooks     = "books"
+	ScanStageCompleted = "completed"
+	ScanStageFailed    = "failed"
+	ScanStageCancelled = "cancelled"
+)
+
+type ScanEvent struct {
+	LibraryID int64  `json:"library_id"`
+	Stage     string `json:"stage"`
+	Name      string `json:"name,omitempty"`
+	Done      int    `json:"done"`
+	Total     int    `json:"total"`
+	Error     string `json:"error,omitempty"`
+	Time      string `json:"time"`
+}
+
+const (
+	ScanJobStatusRunning   = "running"
+	ScanJobStatusCompleted = "completed"
+	ScanJobStatusFailed    = "failed"
+	ScanJobStatusCancelled = "cancelled"
+)
+
+type ScanJob struct {
+	ID        string  `json:"id"`
+	LibraryID int64   `json:"library_id"`
+	Status    string  `json:"status"`
+	StartedAt string  `json:"started_at"`
+	EndedAt   *string `json:"ended_at"`
+	Error     string  `json:"error,omitempty"`
+}
+
+const (
+	ScanErrorStageCover  = "cover"
+	ScanErrorStageFolder = "folder"
+	ScanErrorStageTitle  = "title"
+	ScanErrorStageBook   = "book"
+)
+
+type ScanError struct {
+	ID        int64  `json:"id" db:"ID"`
+	Path      string `json:"path" db:"PATH"`
+	TitleURL  string `json:"title_url" db:"TITLE_URL"`
+	Stage     string `json:"stage" db:"STAGE"`
+	Message   string `json:"message" db:"MESSAGE"`
+	CreatedAt string `json:"created_at" db:"CREATED_AT"`
+	LibraryID int64  `json:"library_id" db:"LIBRARY_ID"`
+}
+
+const (
+	TitleFieldName      = "name"
+	TitleFieldURL       = "url"
+	TitleFieldGroupPath = "group_path"
+	TitleFieldCreatedAt = "created_at"
+	TitleFieldUpdatedAt = "updated_at"
+	TitleFieldCover     = "cover"
+	TitleFieldCoverHash = "cover_hash"
+	TitleFieldLangs     = "langs"
+	TitleFieldBookCount = "book_count"
+	TitleFieldTags      = "tags"
+	TitleFieldAuthors   = "authors"
+	TitleFieldCircles   = "circles"
+
+	BookFieldName        = "name"
+	BookFieldURL         = "url"
+	BookFieldTitle       = "title"
+	BookFieldUpdatedAt   = "updated_at"
+	BookFieldPageCount   = "page_count"
+	BookFieldPreview     = "preview"
+	BookFieldFingerprint = "fingerprint"
+	BookFieldTags        = "tags"
+)
+
+type FieldChange struct {
+	Field  string      `json:"field"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type ScanDiff struct {
+	LibraryID     int64        `json:"library_id"`
+	AddedTitles   []*TitleDiff `json:"added_titles"`
+	RemovedTitles []*TitleDiff `json:"removed_titles"`
+	UpdatedTitles []*TitleDiff `json:"updated_titles"`
+	Errors        []*ScanError `json:"errors"`
+
+	UnchangedTitles []*TitleDiff `json:"-"`
+}
+
+type TitleDiff struct {
+	ID           int64          `json:"id,omitempty"`
+	Name         string         `json:"name"`
+	URL          string         `json:"url"`
+	Changes      []*FieldChange `json:"changes,omitempty"`
+	AddedBooks   []*BookDiff    `json:"added_books,omitempty"`
+	RemovedBooks []*BookDiff    `json:"removed_books,omitempty"`
+	UpdatedBooks []*BookDiff    `json:"updated_books,omitempty"`
+
+	UnchangedBooks []*BookDiff `json:"-"`
+	Title          *Title      `json:"-"`
+	DBTitle        *Title      `json:"-"`
+}
+
+type BookDiff struct {
+	ID      int64          `json:"id,omitempty"`
+	Name    string         `json:"name"`
+	URL     string         `json:"url"`
+	Changes []*FieldChange `json:"changes,omitempty"`
+
+	Book   *Book `json:"-"`
+	DBBook *Book `json:"-"`
 }
@@ -0,0 +1,25 @@
+package model
+
+// BookSidecarVersion is the version of the sidecar format written for books,
+// sidecars written before the format was versioned only hold the favorite page numbers,
+// sidecars of version 1 hold them along with the version
+const BookSidecarVersion = 2
+
+// FavoritesTimeFormat is the format of the times favorites were changed at,
+// with a fixed number of digits so that times in UTC compare as strings
+const FavoritesTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// BookSidecar is the state of a book kept in a JSON file beside its archive,
+// so that it survives rescanning the book or rebuilding the DB
+type BookSidecar struct {
+	Version            int                `json:"version"`
+	FavoritesUpdatedAt *string            `json:"favorites_updated_at,omitempty"`
+	FavoritePages      []*BookSidecarPage `json:"favorite_pages"`
+}
+
+// BookSidecarPage is identified by the name of its file in the book archive, which stays the same
+// when pages are added to or removed from the archive, the page number is only used without file name
+type BookSidecarPage struct {
+	File   string `json:"file,omitempty"`
+	Number int    `json:"number"`
+}
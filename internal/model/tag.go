@@ -0,0 +1,17 @@
+package model
+
+type Tag struct {
+	ID   int64  `json:"id" db:"ID"`
+	Name string `json:"name" db:"NAME"`
+}
+
+type TitleTag struct {
+	TitleID int64  `db:"TITLE_ID"`
+	Name    string `db:"NAME"`
+}
+
+type BookTag struct {
+	BookID  int64  `db:"BOOK_ID"`
+	BookURL string `db:"BOOK_URL"`
+	Name    string `db:"NAME"`
+}
@@ -1,18 +1,27 @@
 package model
 
 type Title struct {
-	ID          int64  `json:"id" db:"ID"`
-	Name        string `json:"name" db:"NAME"`
-	URL         string `json:"url" db:"URL"`
-	CreatedAt   string `json:"created_at" db:"CREATED_AT"`
-	UpdatedAt   string `json:"updated_at" db:"UPDATED_AT"`
-	CoverWidth  int    `json:"cover_width" db:"COVER_WIDTH"`
-	CoverHeight int    `json:"cover_height" db:"COVER_HEIGHT"`
-	BookCount   int    `json:"book_count" db:"BOOK_COUNT"`
-	Uncensored  int    `json:"uncensored" db:"UNCENSORED"`
-	Waifu2x     int    `json:"waifu2x" db:"WAIFU2X"`
-	Langs       string `json:"langs" db:"LANGS"`
-	LibraryID   int64  `json:"library_id" db:"LIBRARY_ID"`
+	ID          int64    `json:"id" db:"ID"`
+	Name        string   `json:"name" db:"NAME"`
+	URL         string   `json:"url" db:"URL"`
+	GroupPath   string   `json:"group_path" db:"GROUP_PATH"`
+	CreatedAt   string   `json:"created_at" db:"CREATED_AT"`
+	UpdatedAt   string   `json:"updated_at" db:"UPDATED_AT"`
+	CoverWidth  int      `json:"cover_width" db:"COVER_WIDTH"`
+	CoverHeight int      `json:"cover_height" db:"COVER_HEIGHT"`
+	BookCount   int      `json:"book_count" db:"BOOK_COUNT"`
+	Langs       string   `json:"langs" db:"LANGS"`
+	LibraryID   int64    `json:"library_id" db:"LIBRARY_ID"`
+	CoverHash   *string  `json:"cover_hash" db:"COVER_HASH"`
+	ReadAt      *string  `json:"read_at" db:"READ_AT"`
+	Tags        []string `json:"tags" db:"-"`
+	Authors     []string `json:"authors" db:"-"`
+	Circles     []string `json:"circles" db:"-"`
+
+	// CreatedAtFromName tells whether the created time was parsed from the title name during a scan
+	CreatedAtFromName bool `json:"-" db:"-"`
+	// SortKey is the value titles were ordered by in a search, cursors of the next page start after it
+	SortKey interface{} `json:"-" db:"SORT_KEY"`
 }
 
 type TitleQuery struct {
@@ -20,5 +29,45 @@ type TitleQuery struct {
 	Page       int
 	Size       int
 	Sort       string
+	Order      string
+	Seed       string
 	Search     string
+	Tags       []string
+	AuthorIDs  []string
+	Filter     string
+	Cursor     string
+
+	// FilterNode combines all conditions of the query, it is built from the fields above before searching
+	FilterNode FilterNode
+	// After is the decoded cursor, titles are listed from the start when it is nil
+	After *TitleCursor
+}
+
+const (
+	TitleSortRelevance     = "relevance"
+	TitleSortCreatedAt     = "created_at"
+	TitleSortName          = "name"
+	TitleSortUpdatedAt     = "updated_at"
+	TitleSortBookCount     = "book_count"
+	TitleSortPageCount     = "page_count"
+	TitleSortFavoriteCount = "favorite_count"
+	TitleSortReadAt        = "read_at"
+	TitleSortRandom        = "random"
+
+	TitleOrderAscending  = "asc"
+	TitleOrderDescending = "desc"
+)
+
+// TitleCursor marks the last title of a page by the key of the sort order and its ID
+type TitleCursor struct {
+	Sort  string      `json:"s"`
+	Order string      `json:"o,omitempty"`
+	Seed  string      `json:"r,omitempty"`
+	Key   interface{} `json:"k"`
+	ID    int64       `json:"i"`
+}
+
+type TitlePage struct {
+	Titles     []*Title `json:"titles"`
+	NextCursor *string  `json:"next_cursor"`
 }
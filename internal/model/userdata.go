@@ -0,0 +1,50 @@
+package model
+
+// UserDataVersion is the version of the format of exported user data
+const UserDataVersion = 1
+
+// UserData is the state created by users of the server, keyed by paths relative to library roots
+// and by page file names instead of IDs, so that it can be imported again after titles are recreated,
+// the DB is rebuilt or the libraries are moved to another server
+type UserData struct {
+	Version    int                `json:"version"`
+	ExportedAt string             `json:"exported_at"`
+	Libraries  []*UserDataLibrary `json:"libraries"`
+}
+
+// UserDataLibrary is matched by its root when importing, then by its name for libraries which moved
+type UserDataLibrary struct {
+	Name   string           `json:"name"`
+	Root   string           `json:"root"`
+	Titles []*UserDataTitle `json:"titles"`
+}
+
+type UserDataTitle struct {
+	Path   string          `json:"path"`
+	ReadAt *string         `json:"read_at,omitempty"`
+	Books  []*UserDataBook `json:"books,omitempty"`
+}
+
+type UserDataBook struct {
+	Path          string          `json:"path"`
+	FavoritePages []*UserDataPage `json:"favorite_pages"`
+}
+
+// UserDataPage is identified by the name of its file in the book archive,
+// the page number is only used when the file name could not be read on export
+type UserDataPage struct {
+	File   string `json:"file,omitempty"`
+	Number int    `json:"number"`
+}
+
+// UserDataImport tells how much of imported user data was attached to the current libraries,
+// unmatched paths are the titles, books and pages which do not exist anymore
+type UserDataImport struct {
+	TitlesMatched   int      `json:"titles_matched"`
+	TitlesUnmatched int      `json:"titles_unmatched"`
+	BooksMatched    int      `json:"books_matched"`
+	BooksUnmatched  int      `json:"books_unmatched"`
+	PagesMatched    int      `json:"pages_matched"`
+	PagesUnmatched  int      `json:"pages_unmatched"`
+	UnmatchedPaths  []string `json:"unmatched_paths"`
+}
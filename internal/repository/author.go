@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type RepositoryAuthor interface {
+	InsertIfNotExists(context.Context, sqlite.DBOps, string) error
+	FindAll(context.Context, sqlite.DBOps) ([]*model.Author, error)
+	FindByID(context.Context, sqlite.DBOps, string) (*model.Author, error)
+	FindAllByNames(context.Context, sqlite.DBOps, []string) ([]*model.Author, error)
+}
+
+type repositoryAuthor struct {
+}
+
+func NewRepositoryAuthor() RepositoryAuthor {
+	return &repositoryAuthor{}
+}
+
+func (r *repositoryAuthor) InsertIfNotExists(ctx context.Context, dbOps sqlite.DBOps, name string) error {
+	query := "INSERT OR IGNORE INTO AUTHOR (NAME) " +
+		"VALUES (?)"
+
+	_, err := dbOps.ExecContext(ctx, query, name)
+	if err != nil {
+		return fmt.Errorf("rAuthor - failed to add new row to table AUTHOR: %w", err)
+	}
+
+	return nil
+}
+
+// FindAll returns the authors which have titles, along with the number of their titles
+func (r *repositoryAuthor) FindAll(ctx context.Context, dbOps sqlite.DBOps) ([]*model.Author, error) {
+	query := "SELECT AUTHOR.ID, AUTHOR.NAME, COUNT(*) AS TITLE_COUNT FROM AUTHOR " +
+		"JOIN TITLE_AUTHOR ON TITLE_AUTHOR.AUTHOR_ID = AUTHOR.ID " +
+		"GROUP BY AUTHOR.ID " +
+		"ORDER BY AUTHOR.NAME COLLATE NOCASE ASC"
+
+	authors := []*model.Author{}
+
+	err := dbOps.SelectContext(ctx, &authors, query)
+	if err != nil {
+		return nil, fmt.Errorf("rAuthor - failed to find all rows from table AUTHOR: %w", err)
+	}
+
+	return authors, nil
+}
+
+func (r *repositoryAuthor) FindByID(ctx context.Context, dbOps sqlite.DBOps, authorID string) (*model.Author, error) {
+	query := "SELECT AUTHOR.ID, AUTHOR.NAME, " +
+		"(SELECT COUNT(*) FROM TITLE_AUTHOR WHERE TITLE_AUTHOR.AUTHOR_ID = AUTHOR.ID) AS TITLE_COUNT FROM AUTHOR " +
+		"WHERE AUTHOR.ID = ?"
+
+	author := model.Author{}
+
+	err := dbOps.GetContext(ctx, &author, query, authorID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("rAuthor - %w: no matched rows with specific ID from table AUTHOR", model.ErrNotFound)
+	} else if err != nil {
+		return nil, fmt.Errorf("rAuthor - failed to find row with specific ID from table AUTHOR: %w", err)
+	}
+
+	return &author, nil
+}
+
+func (r *repositoryAuthor) FindAllByNames(ctx context.Context, dbOps sqlite.DBOps, names []string) ([]*model.Author, error) {
+	authors := []*model.Author{}
+	if len(names) == 0 {
+		return authors, nil
+	}
+
+	query, args, err := sqlx.In("SELECT ID, NAME FROM AUTHOR WHERE NAME IN (?)", names)
+	if err != nil {
+		return nil, fmt.Errorf("rAuthor - failed to bind variables for SQL query: %w", err)
+	}
+	query = dbOps.Rebind(query)
+
+	err = dbOps.SelectContext(ctx, &authors, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("rAuthor - failed to find rows with given names from table AUTHOR: %w", err)
+	}
+
+	return authors, nil
+}
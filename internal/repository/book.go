@@ -13,11 +13,14 @@ type RepositoryBook interface {
 	Insert(context.Context, sqlite.DBOps, *model.Book) error
 	FindByID(context.Context, sqlite.DBOps, string) (*model.Book, error)
 	FindAllByTitleID(context.Context, sqlite.DBOps, string) ([]*model.Book, error)
+	FindAllByLibraryID(context.Context, sqlite.DBOps, string) ([]*model.Book, error)
+	UpdateLocation(context.Context, sqlite.DBOps, string, string, string) error
+	UpdateTitleID(context.Context, sqlite.DBOps, string, int64) error
 	UpdateModifiedTime(context.Context, sqlite.DBOps, string, string) error
 	UpdatePreview(context.Context, sqlite.DBOps, string, *string, *string) error
 	UpdatePageCount(context.Context, sqlite.DBOps, string, int) error
-	DeleteAllByTitleID(context.Context, sqlite.DBOps, string) error
-	DeleteAllByLibraryID(context.Context, sqlite.DBOps, string) error
+	UpdateFingerprint(context.Context, sqlite.DBOps, string, *string) error
+	UpdateFavoritesTime(context.Context, sqlite.DBOps, string, *string) error
 	DeleteByID(context.Context, sqlite.DBOps, string) error
 }
 
@@ -29,10 +32,10 @@ func NewRepositoryBook() RepositoryBook {
 }
 
 func (r *repositoryBook) Insert(ctx context.Context, db sqlite.DBOps, book *model.Book) error {
-	query := "INSERT INTO BOOK (NAME, URL, CREATED_AT, UPDATED_AT, PREVIEW_URL, PREVIEW_UPDATED_AT, PAGE_COUNT, TITLE_ID, LIBRARY_ID) " +
-		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	query := "INSERT INTO BOOK (NAME, URL, CREATED_AT, UPDATED_AT, PREVIEW_URL, PREVIEW_UPDATED_AT, PAGE_COUNT, FINGERPRINT, TITLE_ID, LIBRARY_ID) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 
-	result, err := db.ExecContext(ctx, query, book.Name, book.URL, book.CreatedAt, book.UpdatedAt, book.PreviewURL, book.PreviewUpdatedAt, book.PageCount, book.TitleID, book.LibraryID)
+	result, err := db.ExecContext(ctx, query, book.Name, book.URL, book.CreatedAt, book.UpdatedAt, book.PreviewURL, book.PreviewUpdatedAt, book.PageCount, book.Fingerprint, book.TitleID, book.LibraryID)
 	if err != nil {
 		return fmt.Errorf("rBook - failed to add new row to table BOOK: %w", err)
 	}
@@ -78,6 +81,46 @@ func (r *repositoryBook) FindAllByTitleID(ctx context.Context, dbOps sqlite.DBOp
 	return books, nil
 }
 
+func (r *repositoryBook) FindAllByLibraryID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) ([]*model.Book, error) {
+	query := "SELECT * FROM BOOK " +
+		"WHERE LIBRARY_ID = ?"
+
+	books := []*model.Book{}
+
+	err := dbOps.SelectContext(ctx, &books, query, libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("rBook - failed to find rows with specific LIBRARY_ID from table BOOK: %w", err)
+	}
+
+	return books, nil
+}
+
+func (r *repositoryBook) UpdateLocation(ctx context.Context, dbOps sqlite.DBOps, bookID string, name string, url string) error {
+	query := "UPDATE BOOK " +
+		"SET NAME = ?, URL = ? " +
+		"WHERE ID = ?"
+
+	_, err := dbOps.ExecContext(ctx, query, name, url, bookID)
+	if err != nil {
+		return fmt.Errorf("rBook - failed to update NAME & URL fields for row with given ID from table BOOK: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repositoryBook) UpdateTitleID(ctx context.Context, dbOps sqlite.DBOps, bookID string, titleID int64) error {
+	query := "UPDATE BOOK " +
+		"SET TITLE_ID = ? " +
+		"WHERE ID = ?"
+
+	_, err := dbOps.ExecContext(ctx, query, titleID, bookID)
+	if err != nil {
+		return fmt.Errorf("rBook - failed to update TITLE_ID field for row with given ID from table BOOK: %w", err)
+	}
+
+	return nil
+}
+
 func (r *repositoryBook) UpdateModifiedTime(ctx context.Context, dbOps sqlite.DBOps, bookID string, modTime string) error {
 	query := "UPDATE BOOK " +
 		"SET UPDATED_AT = ? " +
@@ -117,25 +160,27 @@ func (r *repositoryBook) UpdatePageCount(ctx context.Context, dbOps sqlite.DBOps
 	return nil
 }
 
-func (r *repositoryBook) DeleteAllByTitleID(ctx context.Context, dbOps sqlite.DBOps, titleID string) error {
-	query := "DELETE FROM BOOK " +
-		"WHERE TITLE_ID = ?"
+func (r *repositoryBook) UpdateFingerprint(ctx context.Context, dbOps sqlite.DBOps, bookID string, fingerprint *string) error {
+	query := "UPDATE BOOK " +
+		"SET FINGERPRINT = ? " +
+		"WHERE ID = ?"
 
-	_, err := dbOps.ExecContext(ctx, query, titleID)
+	_, err := dbOps.ExecContext(ctx, query, fingerprint, bookID)
 	if err != nil {
-		return fmt.Errorf("rBook - failed to delete rows with given BOOK_ID from table BOOK: %w", err)
+		return fmt.Errorf("rBook - failed to update FINGERPRINT field for row with given ID from table BOOK: %w", err)
 	}
 
 	return nil
 }
 
-func (r *repositoryBook) DeleteAllByLibraryID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) error {
-	query := "DELETE FROM BOOK " +
-		"WHERE LIBRARY_ID = ?"
+func (r *repositoryBook) UpdateFavoritesTime(ctx context.Context, dbOps sqlite.DBOps, bookID string, favoritesTime *string) error {
+	query := "UPDATE BOOK " +
+		"SET FAVORITES_UPDATED_AT = ? " +
+		"WHERE ID = ?"
 
-	_, err := dbOps.ExecContext(ctx, query, libraryID)
+	_, err := dbOps.ExecContext(ctx, query, favoritesTime, bookID)
 	if err != nil {
-		return fmt.Errorf("rBook - failed to delete rows with given LIBRARY_ID from table BOOK: %w", err)
+		return fmt.Errorf("rBook - failed to update FAVORITES_UPDATED_AT field for row with given ID from table BOOK: %w", err)
 	}
 
 	return nil
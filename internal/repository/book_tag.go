@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type RepositoryBookTag interface {
+	Insert(context.Context, sqlite.DBOps, int64, int64, int64, int64) error
+	FindAllByBookIDs(context.Context, sqlite.DBOps, []int64) ([]*model.BookTag, error)
+	FindAllByLibraryID(context.Context, sqlite.DBOps, string) ([]*model.BookTag, error)
+	UpdateTitleIDByBookID(context.Context, sqlite.DBOps, string, int64) error
+	DeleteAllByBookID(context.Context, sqlite.DBOps, string) error
+}
+
+type repositoryBookTag struct {
+}
+
+func NewRepositoryBookTag() RepositoryBookTag {
+	return &repositoryBookTag{}
+}
+
+func (r *repositoryBookTag) Insert(ctx context.Context, dbOps sqlite.DBOps, bookID int64, tagID int64, titleID int64, libraryID int64) error {
+	query := "INSERT INTO BOOK_TAG (BOOK_ID, TAG_ID, TITLE_ID, LIBRARY_ID) " +
+		"VALUES (?, ?, ?, ?)"
+
+	_, err := dbOps.ExecContext(ctx, query, bookID, tagID, titleID, libraryID)
+	if err != nil {
+		return fmt.Errorf("rBookTag - failed to add new row to table BOOK_TAG: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repositoryBookTag) FindAllByBookIDs(ctx context.Context, dbOps sqlite.DBOps, bookIDs []int64) ([]*model.BookTag, error) {
+	bookTags := []*model.BookTag{}
+	if len(bookIDs) == 0 {
+		return bookTags, nil
+	}
+
+	queryString := "SELECT BOOK_TAG.BOOK_ID, BOOK.URL AS BOOK_URL, TAG.NAME FROM BOOK_TAG " +
+		"JOIN BOOK ON BOOK.ID = BOOK_TAG.BOOK_ID " +
+		"JOIN TAG ON TAG.ID = BOOK_TAG.TAG_ID " +
+		"WHERE BOOK_TAG.BOOK_ID IN (?) " +
+		"ORDER BY TAG.NAME ASC"
+	query, args, err := sqlx.In(queryString, bookIDs)
+	if err != nil {
+		return nil, fmt.Errorf("rBookTag - failed to bind variables for SQL query: %w", err)
+	}
+	query = dbOps.Rebind(query)
+
+	err = dbOps.SelectContext(ctx, &bookTags, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("rBookTag - failed to find rows with given BOOK_IDs from table BOOK_TAG: %w", err)
+	}
+
+	return bookTags, nil
+}
+
+func (r *repositoryBookTag) FindAllByLibraryID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) ([]*model.BookTag, error) {
+	query := "SELECT BOOK_TAG.BOOK_ID, BOOK.URL AS BOOK_URL, TAG.NAME FROM BOOK_TAG " +
+		"JOIN BOOK ON BOOK.ID = BOOK_TAG.BOOK_ID " +
+		"JOIN TAG ON TAG.ID = BOOK_TAG.TAG_ID " +
+		"WHERE BOOK_TAG.LIBRARY_ID = ? " +
+		"ORDER BY TAG.NAME ASC"
+
+	bookTags := []*model.BookTag{}
+
+	err := dbOps.SelectContext(ctx, &bookTags, query, libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("rBookTag - failed to find rows with given LIBRARY_ID from table BOOK_TAG: %w", err)
+	}
+
+	return bookTags, nil
+}
+
+func (r *repositoryBookTag) UpdateTitleIDByBookID(ctx context.Context, dbOps sqlite.DBOps, bookID string, titleID int64) error {
+	query := "UPDATE BOOK_TAG " +
+		"SET TITLE_ID = ? " +
+		"WHERE BOOK_ID = ?"
+
+	_, err := dbOps.ExecContext(ctx, query, titleID, bookID)
+	if err != nil {
+		return fmt.Errorf("rBookTag - failed to update TITLE_ID field for rows with given BOOK_ID from table BOOK_TAG: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repositoryBookTag) DeleteAllByBookID(ctx context.Context, dbOps sqlite.DBOps, bookID string) error {
+	query := "DELETE FROM BOOK_TAG " +
+		"WHERE BOOK_ID = ?"
+
+	_, err := dbOps.ExecContext(ctx, query, bookID)
+	if err != nil {
+		return fmt.Errorf("rBookTag - failed to delete rows with given BOOK_ID from table BOOK_TAG: %w", err)
+	}
+
+	return nil
+}
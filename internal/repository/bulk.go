@@ -0,0 +1,17 @@
+package repository
+
+import "strings"
+
+// bulkInsertMaxVariables keeps bulk inserts below the limit of variables SQLite allows in a statement
+const bulkInsertMaxVariables = 999
+
+// bulkInsertBatchSize returns how many rows with given number of columns fit in a single bulk insert
+func bulkInsertBatchSize(numColumns int) int {
+	return bulkInsertMaxVariables / numColumns
+}
+
+// bulkInsertValues builds the placeholders of a multi-row VALUES clause
+func bulkInsertValues(numRows int, numColumns int) string {
+	row := "(" + strings.TrimSuffix(strings.Repeat("?, ", numColumns), ", ") + ")"
+	return strings.TrimSuffix(strings.Repeat(row+", ", numRows), ", ")
+}
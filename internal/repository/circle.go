@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type RepositoryCircle interface {
+	InsertIfNotExists(context.Context, sqlite.DBOps, string) error
+	FindAllByNames(context.Context, sqlite.DBOps, []string) ([]*model.Circle, error)
+}
+
+type repositoryCircle struct {
+}
+
+func NewRepositoryCircle() RepositoryCircle {
+	return &repositoryCircle{}
+}
+
+func (r *repositoryCircle) InsertIfNotExists(ctx context.Context, dbOps sqlite.DBOps, name string) error {
+	query := "INSERT OR IGNORE INTO CIRCLE (NAME) " +
+		"VALUES (?)"
+
+	_, err := dbOps.ExecContext(ctx, query, name)
+	if err != nil {
+		return fmt.Errorf("rCircle - failed to add new row to table CIRCLE: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repositoryCircle) FindAllByNames(ctx context.Context, dbOps sqlite.DBOps, names []string) ([]*model.Circle, error) {
+	circles := []*model.Circle{}
+	if len(names) == 0 {
+		return circles, nil
+	}
+
+	query, args, err := sqlx.In("SELECT * FROM CIRCLE WHERE NAME IN (?)", names)
+	if err != nil {
+		return nil, fmt.Errorf("rCircle - failed to bind variables for SQL query: %w", err)
+	}
+	query = dbOps.Rebind(query)
+
+	err = dbOps.SelectContext(ctx, &circles, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("rCircle - failed to find rows with given names from table CIRCLE: %w", err)
+	}
+
+	return circles, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/imouto1994/yume/internal/model"
+)
+
+var filterComparators = map[string]string{
+	model.FilterOperatorEqual:        "=",
+	model.FilterOperatorGreater:      ">",
+	model.FilterOperatorGreaterEqual: ">=",
+	model.FilterOperatorLess:         "<",
+	model.FilterOperatorLessEqual:    "<=",
+}
+
+// compileTitleFilter compiles the syntax tree of a title filter into a condition on table TITLE,
+// values are always bound as parameters
+func compileTitleFilter(node model.FilterNode) (string, []interface{}, error) {
+	switch node := node.(type) {
+	case *model.FilterAnd:
+		return compileTitleFilterNodes(node.Nodes, " AND ")
+	case *model.FilterOr:
+		return compileTitleFilterNodes(node.Nodes, " OR ")
+	case *model.FilterNot:
+		condition, args, err := compileTitleFilter(node.Node)
+		if err != nil {
+			return "", nil, err
+		}
+		return "NOT " + condition, args, nil
+	case *model.FilterTerm:
+		return compileTitleFilterTerm(node)
+	}
+
+	return "", nil, fmt.Errorf("rTitle - unknown node type %T of title filter", node)
+}
+
+func compileTitleFilterNodes(nodes []model.FilterNode, separator string) (string, []interface{}, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	for _, node := range nodes {
+		condition, nodeArgs, err := compileTitleFilter(node)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, condition)
+		args = append(args, nodeArgs...)
+	}
+
+	return "(" + strings.Join(conditions, separator) + ")", args, nil
+}
+
+func compileTitleFilterTerm(term *model.FilterTerm) (string, []interface{}, error) {
+	comparator, ok := filterComparators[term.Operator]
+	if !ok {
+		return "", nil, fmt.Errorf("rTitle - unknown operator %s of title filter term", term.Operator)
+	}
+
+	switch term.Field {
+	case model.FilterFieldText:
+		return "ID IN (SELECT rowid FROM TITLE_SEARCH WHERE TITLE_SEARCH MATCH ?)", []interface{}{buildTitleSearchMatch(term.Value)}, nil
+	case model.FilterFieldLibrary:
+		return "LIBRARY_ID = ?", []interface{}{term.Value}, nil
+	case model.FilterFieldName:
+		return "NAME LIKE ? ESCAPE '\\'", []interface{}{"%" + escapeLikePattern(term.Value) + "%"}, nil
+	case model.FilterFieldTag:
+		return "ID IN (" +
+			"SELECT TITLE_TAG.TITLE_ID FROM TITLE_TAG " +
+			"JOIN TAG ON TAG.ID = TITLE_TAG.TAG_ID " +
+			"WHERE TAG.NAME = ? COLLATE NOCASE)", []interface{}{term.Value}, nil
+	case model.FilterFieldAuthor:
+		return "ID IN (" +
+			"SELECT TITLE_AUTHOR.TITLE_ID FROM TITLE_AUTHOR " +
+			"JOIN AUTHOR ON AUTHOR.ID = TITLE_AUTHOR.AUTHOR_ID " +
+			"WHERE AUTHOR.NAME = ? COLLATE NOCASE)", []interface{}{term.Value}, nil
+	case model.FilterFieldAuthorID:
+		return "ID IN (SELECT TITLE_ID FROM TITLE_AUTHOR WHERE AUTHOR_ID = ?)", []interface{}{term.Value}, nil
+	case model.FilterFieldCircle:
+		return "ID IN (" +
+			"SELECT TITLE_CIRCLE.TITLE_ID FROM TITLE_CIRCLE " +
+			"JOIN CIRCLE ON CIRCLE.ID = TITLE_CIRCLE.CIRCLE_ID " +
+			"WHERE CIRCLE.NAME = ? COLLATE NOCASE)", []interface{}{term.Value}, nil
+	case model.FilterFieldLang:
+		// Languages are stored as a comma separated list
+		return "(',' || LANGS || ',') LIKE ? ESCAPE '\\'", []interface{}{"%," + escapeLikePattern(term.Value) + ",%"}, nil
+	case model.FilterFieldBooks, model.FilterFieldPages:
+		// Counts are bound as integers since the sum of pages has no type affinity to convert text with
+		count, err := strconv.Atoi(term.Value)
+		if err != nil {
+			return "", nil, fmt.Errorf("rTitle - invalid count of title filter term: %w", err)
+		}
+		if term.Field == model.FilterFieldBooks {
+			return "BOOK_COUNT " + comparator + " ?", []interface{}{count}, nil
+		}
+		return "(SELECT COALESCE(SUM(BOOK.PAGE_COUNT), 0) FROM BOOK WHERE BOOK.TITLE_ID = TITLE.ID) " + comparator + " ?", []interface{}{count}, nil
+	case model.FilterFieldAdded:
+		return compileTitleFilterDate("CREATED_AT", term)
+	case model.FilterFieldUpdated:
+		return compileTitleFilterDate("UPDATED_AT", term)
+	}
+
+	return "", nil, fmt.Errorf("rTitle - unknown field %s of title filter term", term.Field)
+}
+
+// compileTitleFilterDate compares a time column with the whole day of a date,
+// e.g. a time is greater than a date when it is on a later day
+func compileTitleFilterDate(column string, term *model.FilterTerm) (string, []interface{}, error) {
+	date, err := time.Parse(model.FilterDateFormat, term.Value)
+	if err != nil {
+		return "", nil, fmt.Errorf("rTitle - invalid date of title filter term: %w", err)
+	}
+	dayStart := date.UTC().Format(time.RFC3339)
+	dayEnd := date.AddDate(0, 0, 1).UTC().Format(time.RFC3339)
+
+	switch term.Operator {
+	case model.FilterOperatorGreater:
+		return column + " >= ?", []interface{}{dayEnd}, nil
+	case model.FilterOperatorGreaterEqual:
+		return column + " >= ?", []interface{}{dayStart}, nil
+	case model.FilterOperatorLess:
+		return column + " < ?", []interface{}{dayStart}, nil
+	case model.FilterOperatorLessEqual:
+		return column + " < ?", []interface{}{dayEnd}, nil
+	}
+
+	return "(" + column + " >= ? AND " + column + " < ?)", []interface{}{dayStart, dayEnd}, nil
+}
+
+func escapeLikePattern(value string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(value)
+}
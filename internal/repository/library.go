@@ -13,6 +13,9 @@ type RepositoryLibrary interface {
 	Insert(context.Context, sqlite.DBOps, *model.Library) error
 	FindAll(context.Context, sqlite.DBOps) ([]*model.Library, error)
 	FindByID(context.Context, sqlite.DBOps, string) (*model.Library, error)
+	Update(context.Context, sqlite.DBOps, *model.Library) error
+	UpdateScanRunTimes(context.Context, sqlite.DBOps, string, *string, *string) error
+	GetTotals(context.Context, sqlite.DBOps, string) (*model.LibraryTotals, error)
 	DeleteByID(context.Context, sqlite.DBOps, string) error
 }
 
@@ -24,10 +27,10 @@ func NewRepositoryLibrary() RepositoryLibrary {
 }
 
 func (r *repositoryLibrary) Insert(ctx context.Context, dbOps sqlite.DBOps, library *model.Library) error {
-	query := "INSERT INTO LIBRARY (NAME, ROOT) " +
-		"VALUES (?, ?)"
+	query := "INSERT INTO LIBRARY (NAME, ROOT, SCAN_WORKERS, SCAN_DEPTH, CONVENTION, INCLUDE_PATTERNS, EXCLUDE_PATTERNS, INCLUDE_HIDDEN, SCAN_SCHEDULE) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
 
-	result, err := dbOps.ExecContext(ctx, query, library.Name, library.Root)
+	result, err := dbOps.ExecContext(ctx, query, library.Name, library.Root, library.ScanWorkers, library.ScanDepth, library.Convention, library.IncludePatterns, library.ExcludePatterns, library.IncludeHidden, library.ScanSchedule)
 	if err != nil {
 		return fmt.Errorf("rLibrary - failed to add new row to table LIBRARY: %w", err)
 	}
@@ -70,6 +73,48 @@ func (r *repositoryLibrary) FindByID(ctx context.Context, dbOps sqlite.DBOps, li
 	return &library, nil
 }
 
+func (r *repositoryLibrary) Update(ctx context.Context, dbOps sqlite.DBOps, library *model.Library) error {
+	query := "UPDATE LIBRARY " +
+		"SET NAME = ?, ROOT = ?, SCAN_WORKERS = ?, SCAN_DEPTH = ?, CONVENTION = ?, INCLUDE_PATTERNS = ?, EXCLUDE_PATTERNS = ?, INCLUDE_HIDDEN = ?, SCAN_SCHEDULE = ? " +
+		"WHERE ID = ?"
+
+	_, err := dbOps.ExecContext(ctx, query, library.Name, library.Root, library.ScanWorkers, library.ScanDepth, library.Convention, library.IncludePatterns, library.ExcludePatterns, library.IncludeHidden, library.ScanSchedule, library.ID)
+	if err != nil {
+		return fmt.Errorf("rLibrary - failed to update row with given ID from table LIBRARY: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repositoryLibrary) UpdateScanRunTimes(ctx context.Context, dbOps sqlite.DBOps, libraryID string, lastRunAt *string, nextRunAt *string) error {
+	query := "UPDATE LIBRARY " +
+		"SET SCAN_LAST_RUN_AT = ?, SCAN_NEXT_RUN_AT = ? " +
+		"WHERE ID = ?"
+
+	_, err := dbOps.ExecContext(ctx, query, lastRunAt, nextRunAt, libraryID)
+	if err != nil {
+		return fmt.Errorf("rLibrary - failed to update SCAN_LAST_RUN_AT & SCAN_NEXT_RUN_AT fields for row with given ID from table LIBRARY: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repositoryLibrary) GetTotals(ctx context.Context, dbOps sqlite.DBOps, libraryID string) (*model.LibraryTotals, error) {
+	query := "SELECT " +
+		"(SELECT COUNT(*) FROM TITLE WHERE LIBRARY_ID = ?) AS TITLE_COUNT, " +
+		"(SELECT COUNT(*) FROM BOOK WHERE LIBRARY_ID = ?) AS BOOK_COUNT, " +
+		"(SELECT COALESCE(SUM(PAGE_COUNT), 0) FROM BOOK WHERE LIBRARY_ID = ?) AS PAGE_COUNT"
+
+	totals := model.LibraryTotals{}
+
+	err := dbOps.GetContext(ctx, &totals, query, libraryID, libraryID, libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("rLibrary - failed to count titles, books & pages of library with given ID: %w", err)
+	}
+
+	return &totals, nil
+}
+
 func (r *repositoryLibrary) DeleteByID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) error {
 	query := "DELETE FROM LIBRARY " +
 		"WHERE ID = ?"
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+)
+
+type RepositoryLibraryScan interface {
+	Upsert(context.Context, sqlite.DBOps, *model.LibraryScan) error
+	FindByLibraryID(context.Context, sqlite.DBOps, string) (*model.LibraryScan, error)
+}
+
+type repositoryLibraryScan struct {
+}
+
+func NewRepositoryLibraryScan() RepositoryLibraryScan {
+	return &repositoryLibraryScan{}
+}
+
+func (r *repositoryLibraryScan) Upsert(ctx context.Context, dbOps sqlite.DBOps, libraryScan *model.LibraryScan) error {
+	query := "INSERT OR REPLACE INTO LIBRARY_SCAN (LIBRARY_ID, STATUS, STARTED_AT, ENDED_AT, DURATION_MS, " +
+		"TITLES_ADDED, TITLES_REMOVED, TITLES_UPDATED, BOOKS_ADDED, BOOKS_REMOVED, BOOKS_UPDATED, " +
+		"PAGES_ADDED, PAGES_REMOVED, PAGES_UPDATED, ERROR_COUNT) " +
+		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+
+	_, err := dbOps.ExecContext(ctx, query, libraryScan.LibraryID, libraryScan.Status, libraryScan.StartedAt, libraryScan.EndedAt, libraryScan.DurationMS,
+		libraryScan.TitlesAdded, libraryScan.TitlesRemoved, libraryScan.TitlesUpdated, libraryScan.BooksAdded, libraryScan.BooksRemoved, libraryScan.BooksUpdated,
+		libraryScan.PagesAdded, libraryScan.PagesRemoved, libraryScan.PagesUpdated, libraryScan.ErrorCount)
+	if err != nil {
+		return fmt.Errorf("rLibraryScan - failed to add or replace row in table LIBRARY_SCAN: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repositoryLibraryScan) FindByLibraryID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) (*model.LibraryScan, error) {
+	query := "SELECT * FROM LIBRARY_SCAN " +
+		"WHERE LIBRARY_ID = ?"
+
+	libraryScan := model.LibraryScan{}
+
+	err := dbOps.GetContext(ctx, &libraryScan, query, libraryID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("rLibraryScan - %w: no matched rows with specific library ID from table LIBRARY_SCAN", model.ErrNotFound)
+	} else if err != nil {
+		return nil, fmt.Errorf("rLibraryScan - failed to find row with specific library ID from table LIBRARY_SCAN: %w", err)
+	}
+
+	return &libraryScan, nil
+}
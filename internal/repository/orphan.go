@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+)
+
+type RepositoryOrphan interface {
+	FindForeignKeyViolations(context.Context, sqlite.DBOps) ([]*model.ForeignKeyViolation, error)
+	FindSearchRowIDs(context.Context, sqlite.DBOps) ([]int64, error)
+	DeleteByRowID(context.Context, sqlite.DBOps, string, int64) error
+}
+
+type repositoryOrphan struct {
+}
+
+func NewRepositoryOrphan() RepositoryOrphan {
+	return &repositoryOrphan{}
+}
+
+// FindForeignKeyViolations lists the rows of all tables which reference rows not existing anymore
+func (r *repositoryOrphan) FindForeignKeyViolations(ctx context.Context, dbOps sqlite.DBOps) ([]*model.ForeignKeyViolation, error) {
+	query := "PRAGMA foreign_key_check"
+
+	violations := []*model.ForeignKeyViolation{}
+
+	err := dbOps.SelectContext(ctx, &violations, query)
+	if err != nil {
+		return nil, fmt.Errorf("rOrphan - failed to check foreign keys of all tables: %w", err)
+	}
+
+	return violations, nil
+}
+
+// FindSearchRowIDs lists the entries of the search index which titles do not exist anymore,
+// these are not covered by foreign keys since virtual tables cannot have any
+func (r *repositoryOrphan) FindSearchRowIDs(ctx context.Context, dbOps sqlite.DBOps) ([]int64, error) {
+	query := "SELECT rowid FROM TITLE_SEARCH " +
+		"WHERE rowid NOT IN (SELECT ID FROM TITLE)"
+
+	rowIDs := []int64{}
+
+	err := dbOps.SelectContext(ctx, &rowIDs, query)
+	if err != nil {
+		return nil, fmt.Errorf("rOrphan - failed to find rows without title from table TITLE_SEARCH: %w", err)
+	}
+
+	return rowIDs, nil
+}
+
+// DeleteByRowID deletes a row of the given table, which name is expected to come from the database itself
+func (r *repositoryOrphan) DeleteByRowID(ctx context.Context, dbOps sqlite.DBOps, table string, rowID int64) error {
+	query := fmt.Sprintf("DELETE FROM \"%s\" ", table) +
+		"WHERE rowid = ?"
+
+	_, err := dbOps.ExecContext(ctx, query, rowID)
+	if err != nil {
+		return fmt.Errorf("rOrphan - failed to delete row with given rowid from table %s: %w", table, err)
+	}
+
+	return nil
+}
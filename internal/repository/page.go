@@ -9,11 +9,11 @@ import (
 )
 
 type RepositoryPage interface {
-	Insert(context.Context, sqlite.DBOps, *model.Page) error
+	InsertBulk(context.Context, sqlite.DBOps, []*model.Page) error
 	FindAllByBookID(context.Context, sqlite.DBOps, string) ([]*model.Page, error)
+	FindAllFavorite(context.Context, sqlite.DBOps) ([]*model.FavoritePage, error)
 	DeleteAllByBookID(context.Context, sqlite.DBOps, string) error
-	DeleteAllByTitleID(context.Context, sqlite.DBOps, string) error
-	DeleteAllByLibraryID(context.Context, sqlite.DBOps, string) error
+	UpdateTitleIDByBookID(context.Context, sqlite.DBOps, string, int64) error
 	UpdateFavorite(context.Context, sqlite.DBOps, string, int, int) error
 }
 
@@ -24,13 +24,27 @@ func NewRepositoryPage() RepositoryPage {
 	return &repositoryPage{}
 }
 
-func (r *repositoryPage) Insert(ctx context.Context, dbOps sqlite.DBOps, page *model.Page) error {
-	query := "INSERT INTO PAGE (FILE_INDEX, NUMBER, WIDTH, HEIGHT, FAVORITE,BOOK_ID, TITLE_ID, LIBRARY_ID) " +
-		"VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
-
-	_, err := dbOps.ExecContext(ctx, query, page.Index, page.Number, page.Width, page.Height, page.Favorite, page.BookID, page.TitleID, page.LibraryID)
-	if err != nil {
-		return fmt.Errorf("rPage - failed to add new row to table PAGE: %w", err)
+func (r *repositoryPage) InsertBulk(ctx context.Context, dbOps sqlite.DBOps, pages []*model.Page) error {
+	numColumns := 9
+	batchSize := bulkInsertBatchSize(numColumns)
+	for start := 0; start < len(pages); start += batchSize {
+		end := start + batchSize
+		if end > len(pages) {
+			end = len(pages)
+		}
+		batch := pages[start:end]
+
+		query := "INSERT INTO PAGE (FILE_INDEX, NUMBER, WIDTH, HEIGHT, FAVORITE, BOOK_ID, TITLE_ID, LIBRARY_ID, FILE_NAME) " +
+			"VALUES " + bulkInsertValues(len(batch), numColumns)
+		args := make([]interface{}, 0, len(batch)*numColumns)
+		for _, page := range batch {
+			args = append(args, page.Index, page.Number, page.Width, page.Height, page.Favorite, page.BookID, page.TitleID, page.LibraryID, page.FileName)
+		}
+
+		_, err := dbOps.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("rPage - failed to add new rows to table PAGE: %w", err)
+		}
 	}
 
 	return nil
@@ -51,37 +65,43 @@ func (r *repositoryPage) FindAllByBookID(ctx context.Context, dbOps sqlite.DBOps
 	return pages, nil
 }
 
-func (r *repositoryPage) DeleteAllByBookID(ctx context.Context, dbOps sqlite.DBOps, bookID string) error {
-	query := "DELETE FROM PAGE " +
-		"WHERE BOOK_ID = ?"
+// FindAllFavorite lists the favorite pages of all books, grouped by title and book in reading order
+func (r *repositoryPage) FindAllFavorite(ctx context.Context, dbOps sqlite.DBOps) ([]*model.FavoritePage, error) {
+	query := "SELECT PAGE.FILE_INDEX, PAGE.NUMBER, PAGE.FILE_NAME, BOOK.ID AS BOOK_ID, BOOK.URL AS BOOK_URL, BOOK.TITLE_ID, BOOK.LIBRARY_ID FROM PAGE " +
+		"JOIN BOOK ON BOOK.ID = PAGE.BOOK_ID " +
+		"WHERE PAGE.FAVORITE = 1 " +
+		"ORDER BY BOOK.TITLE_ID ASC, BOOK.NAME ASC, BOOK.ID ASC, PAGE.NUMBER ASC"
 
-	_, err := dbOps.ExecContext(ctx, query, bookID)
+	favoritePages := []*model.FavoritePage{}
+
+	err := dbOps.SelectContext(ctx, &favoritePages, query)
 	if err != nil {
-		return fmt.Errorf("rPage -failed to delete rows with given BOOK_ID from table PAGE: %w", err)
+		return nil, fmt.Errorf("rPage - failed to find favorite rows from table PAGE: %w", err)
 	}
 
-	return nil
+	return favoritePages, nil
 }
 
-func (r *repositoryPage) DeleteAllByTitleID(ctx context.Context, dbOps sqlite.DBOps, titleID string) error {
+func (r *repositoryPage) DeleteAllByBookID(ctx context.Context, dbOps sqlite.DBOps, bookID string) error {
 	query := "DELETE FROM PAGE " +
-		"WHERE TITLE_ID = ?"
+		"WHERE BOOK_ID = ?"
 
-	_, err := dbOps.ExecContext(ctx, query, titleID)
+	_, err := dbOps.ExecContext(ctx, query, bookID)
 	if err != nil {
-		return fmt.Errorf("rPage - failed to delete rows with given TITLE_ID from table PAGE: %w", err)
+		return fmt.Errorf("rPage -failed to delete rows with given BOOK_ID from table PAGE: %w", err)
 	}
 
 	return nil
 }
 
-func (r *repositoryPage) DeleteAllByLibraryID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) error {
-	query := "DELETE FROM PAGE " +
-		"WHERE LIBRARY_ID = ?"
+func (r *repositoryPage) UpdateTitleIDByBookID(ctx context.Context, dbOps sqlite.DBOps, bookID string, titleID int64) error {
+	query := "UPDATE PAGE " +
+		"SET TITLE_ID = ? " +
+		"WHERE BOOK_ID = ?"
 
-	_, err := dbOps.ExecContext(ctx, query, libraryID)
+	_, err := dbOps.ExecContext(ctx, query, titleID, bookID)
 	if err != nil {
-		return fmt.Errorf("rPage -failed to delete rows with given LIBRARY_ID from table PAGE: %w", err)
+		return fmt.Errorf("rPage - failed to update TITLE_ID field for rows with given BOOK_ID from table PAGE: %w", err)
 	}
 
 	return nil
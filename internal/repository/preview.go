@@ -9,11 +9,10 @@ import (
 )
 
 type RepositoryPreview interface {
-	Insert(context.Context, sqlite.DBOps, *model.Preview) error
+	InsertBulk(context.Context, sqlite.DBOps, []*model.Preview) error
 	FindAllByBookID(context.Context, sqlite.DBOps, string) ([]*model.Preview, error)
 	DeleteAllByBookID(context.Context, sqlite.DBOps, string) error
-	DeleteAllByTitleID(context.Context, sqlite.DBOps, string) error
-	DeleteAllByLibraryID(context.Context, sqlite.DBOps, string) error
+	UpdateTitleIDByBookID(context.Context, sqlite.DBOps, string, int64) error
 }
 
 type repositoryPreview struct {
@@ -23,13 +22,27 @@ func NewRepositoryPreview() RepositoryPreview {
 	return &repositoryPreview{}
 }
 
-func (r *repositoryPreview) Insert(ctx context.Context, dbOps sqlite.DBOps, preview *model.Preview) error {
-	query := "INSERT INTO PREVIEW (FILE_INDEX, NUMBER, BOOK_ID, TITLE_ID, LIBRARY_ID) " +
-		"VALUES (?, ?, ?, ?, ?)"
-
-	_, err := dbOps.ExecContext(ctx, query, preview.Index, preview.Number, preview.BookID, preview.TitleID, preview.LibraryID)
-	if err != nil {
-		return fmt.Errorf("rPreview - failed to add new row to table PREVIEW: %w", err)
+func (r *repositoryPreview) InsertBulk(ctx context.Context, dbOps sqlite.DBOps, previews []*model.Preview) error {
+	numColumns := 5
+	batchSize := bulkInsertBatchSize(numColumns)
+	for start := 0; start < len(previews); start += batchSize {
+		end := start + batchSize
+		if end > len(previews) {
+			end = len(previews)
+		}
+		batch := previews[start:end]
+
+		query := "INSERT INTO PREVIEW (FILE_INDEX, NUMBER, BOOK_ID, TITLE_ID, LIBRARY_ID) " +
+			"VALUES " + bulkInsertValues(len(batch), numColumns)
+		args := make([]interface{}, 0, len(batch)*numColumns)
+		for _, preview := range batch {
+			args = append(args, preview.Index, preview.Number, preview.BookID, preview.TitleID, preview.LibraryID)
+		}
+
+		_, err := dbOps.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("rPreview - failed to add new rows to table PREVIEW: %w", err)
+		}
 	}
 
 	return nil
@@ -62,25 +75,14 @@ func (r *repositoryPreview) DeleteAllByBookID(ctx context.Context, dbOps sqlite.
 	return nil
 }
 
-func (r *repositoryPreview) DeleteAllByTitleID(ctx context.Context, dbOps sqlite.DBOps, titleID string) error {
-	query := "DELETE FROM PREVIEW " +
-		"WHERE TITLE_ID = ?"
-
-	_, err := dbOps.ExecContext(ctx, query, titleID)
-	if err != nil {
-		return fmt.Errorf("rPreview - failed to delete rows with given TITLE_ID from table PREVIEW: %w", err)
-	}
-
-	return nil
-}
-
-func (r *repositoryPreview) DeleteAllByLibraryID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) error {
-	query := "DELETE FROM PREVIEW " +
-		"WHERE LIBRARY_ID = ?"
+func (r *repositoryPreview) UpdateTitleIDByBookID(ctx context.Context, dbOps sqlite.DBOps, bookID string, titleID int64) error {
+	query := "UPDATE PREVIEW " +
+		"SET TITLE_ID = ? " +
+		"WHERE BOOK_ID = ?"
 
-	_, err := dbOps.ExecContext(ctx, query, libraryID)
+	_, err := dbOps.ExecContext(ctx, query, titleID, bookID)
 	if err != nil {
-		return fmt.Errorf("rPreview -failed to delete rows with given LIBRARY_ID from table PREVIEW: %w", err)
+		return fmt.Errorf("rPreview - failed to update TITLE_ID field for rows with given BOOK_ID from table PREVIEW: %w", err)
 	}
 
 	return nil
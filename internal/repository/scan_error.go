@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+)
+
+type RepositoryScanError interface {
+	Insert(context.Context, sqlite.DBOps, *model.ScanError) error
+	FindAllByLibraryID(context.Context, sqlite.DBOps, string) ([]*model.ScanError, error)
+	DeleteAllByTitleURL(context.Context, sqlite.DBOps, string, string) error
+}
+
+type repositoryScanError struct {
+}
+
+func NewRepositoryScanError() RepositoryScanError {
+	return &repositoryScanError{}
+}
+
+func (r *repositoryScanError) Insert(ctx context.Context, dbOps sqlite.DBOps, scanError *model.ScanError) error {
+	query := "INSERT INTO SCAN_ERROR (PATH, TITLE_URL, STAGE, MESSAGE, CREATED_AT, LIBRARY_ID) " +
+		"VALUES (?, ?, ?, ?, ?, ?)"
+
+	result, err := dbOps.ExecContext(ctx, query, scanError.Path, scanError.TitleURL, scanError.Stage, scanError.Message, scanError.CreatedAt, scanError.LibraryID)
+	if err != nil {
+		return fmt.Errorf("rScanError - failed to add new row to table SCAN_ERROR: %w", err)
+	}
+
+	rowID, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("rScanError - failed to get the ID of inserted row: %w", err)
+	}
+
+	scanError.ID = rowID
+
+	return nil
+}
+
+func (r *repositoryScanError) FindAllByLibraryID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) ([]*model.ScanError, error) {
+	query := "SELECT * FROM SCAN_ERROR " +
+		"WHERE LIBRARY_ID = ? " +
+		"ORDER BY CREATED_AT DESC, ID DESC"
+
+	scanErrors := []*model.ScanError{}
+
+	err := dbOps.SelectContext(ctx, &scanErrors, query, libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("rScanError - failed to find rows with specific LIBRARY_ID from table SCAN_ERROR: %w", err)
+	}
+
+	return scanErrors, nil
+}
+
+func (r *repositoryScanError) DeleteAllByTitleURL(ctx context.Context, dbOps sqlite.DBOps, libraryID string, titleURL string) error {
+	query := "DELETE FROM SCAN_ERROR " +
+		"WHERE LIBRARY_ID = ? AND TITLE_URL = ?"
+
+	_, err := dbOps.ExecContext(ctx, query, libraryID, titleURL)
+	if err != nil {
+		return fmt.Errorf("rScanError - failed to delete rows with given TITLE_URL from table SCAN_ERROR: %w", err)
+	}
+
+	return nil
+}
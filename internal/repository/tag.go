@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type RepositoryTag interface {
+	InsertIfNotExists(context.Context, sqlite.DBOps, string) error
+	FindAll(context.Context, sqlite.DBOps) ([]*model.Tag, error)
+	FindAllByNames(context.Context, sqlite.DBOps, []string) ([]*model.Tag, error)
+}
+
+type repositoryTag struct {
+}
+
+func NewRepositoryTag() RepositoryTag {
+	return &repositoryTag{}
+}
+
+func (r *repositoryTag) InsertIfNotExists(ctx context.Context, dbOps sqlite.DBOps, name string) error {
+	query := "INSERT OR IGNORE INTO TAG (NAME) " +
+		"VALUES (?)"
+
+	_, err := dbOps.ExecContext(ctx, query, name)
+	if err != nil {
+		return fmt.Errorf("rTag - failed to add new row to table TAG: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repositoryTag) FindAll(ctx context.Context, dbOps sqlite.DBOps) ([]*model.Tag, error) {
+	query := "SELECT * FROM TAG " +
+		"ORDER BY NAME COLLATE NOCASE ASC"
+
+	tags := []*model.Tag{}
+
+	err := dbOps.SelectContext(ctx, &tags, query)
+	if err != nil {
+		return nil, fmt.Errorf("rTag - failed to find all rows from table TAG: %w", err)
+	}
+
+	return tags, nil
+}
+
+func (r *repositoryTag) FindAllByNames(ctx context.Context, dbOps sqlite.DBOps, names []string) ([]*model.Tag, error) {
+	tags := []*model.Tag{}
+	if len(names) == 0 {
+		return tags, nil
+	}
+
+	query, args, err := sqlx.In("SELECT * FROM TAG WHERE NAME IN (?)", names)
+	if err != nil {
+		return nil, fmt.Errorf("rTag - failed to bind variables for SQL query: %w", err)
+	}
+	query = dbOps.Rebind(query)
+
+	err = dbOps.SelectContext(ctx, &tags, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("rTag - failed to find rows with given names from table TAG: %w", err)
+	}
+
+	return tags, nil
+}
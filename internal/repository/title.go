@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"hash/fnv"
 
 	"github.com/imouto1994/yume/internal/infra/sqlite"
 	"github.com/imouto1994/yume/internal/model"
@@ -16,12 +17,14 @@ type RepositoryTitle interface {
 	GetTotalFindResults(context.Context, sqlite.DBOps, *model.TitleQuery) (int, error)
 	FindAllByLibraryID(context.Context, sqlite.DBOps, string) ([]*model.Title, error)
 	FindByID(context.Context, sqlite.DBOps, string) (*model.Title, error)
+	UpdateLocation(context.Context, sqlite.DBOps, string, string, string, string) error
+	UpdateCreatedTime(context.Context, sqlite.DBOps, string, string) error
 	UpdateModifiedTime(context.Context, sqlite.DBOps, string, string) error
 	UpdateCoverDimension(context.Context, sqlite.DBOps, string, int, int) error
+	UpdateCoverHash(context.Context, sqlite.DBOps, string, *string) error
 	UpdateBookCount(context.Context, sqlite.DBOps, string, int) error
-	UpdateUncensored(context.Context, sqlite.DBOps, string, int) error
-	UpdateWaifu2x(context.Context, sqlite.DBOps, string, int) error
 	UpdateLangs(context.Context, sqlite.DBOps, string, string) error
+	UpdateReadTime(context.Context, sqlite.DBOps, string, string) error
 	DeleteAllByLibraryID(context.Context, sqlite.DBOps, string) error
 	DeleteByID(context.Context, sqlite.DBOps, string) error
 }
@@ -34,10 +37,10 @@ func NewRepositoryTitle() RepositoryTitle {
 }
 
 func (r *repositoryTitle) Insert(ctx context.Context, db sqlite.DBOps, title *model.Title) error {
-	query := "INSERT INTO TITLE (NAME, URL, CREATED_AT, UPDATED_AT, COVER_WIDTH, COVER_HEIGHT, BOOK_COUNT, UNCENSORED, WAIFU2X, LANGS, LIBRARY_ID) " +
+	query := "INSERT INTO TITLE (NAME, URL, GROUP_PATH, CREATED_AT, UPDATED_AT, COVER_WIDTH, COVER_HEIGHT, COVER_HASH, BOOK_COUNT, LANGS, LIBRARY_ID) " +
 		"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
 
-	result, err := db.ExecContext(ctx, query, title.Name, title.URL, title.CreatedAt, title.UpdatedAt, title.CoverWidth, title.CoverHeight, title.BookCount, title.Uncensored, title.Waifu2x, title.Langs, title.LibraryID)
+	result, err := db.ExecContext(ctx, query, title.Name, title.URL, title.GroupPath, title.CreatedAt, title.UpdatedAt, title.CoverWidth, title.CoverHeight, title.CoverHash, title.BookCount, title.Langs, title.LibraryID)
 	if err != nil {
 		return fmt.Errorf("rTitle - failed to add new row to table TITLE: %w", err)
 	}
@@ -52,62 +55,114 @@ func (r *repositoryTitle) Insert(ctx context.Context, db sqlite.DBOps, title *mo
 	return nil
 }
 
+// titleSort is an order of title search results, ties are broken by the title ID in the same direction
+// so that every title has a distinct position which cursors can point at
+type titleSort struct {
+	expression string
+	descending bool
+}
+
+var titleSorts = map[string]titleSort{
+	model.TitleSortCreatedAt:     {expression: "TITLE.CREATED_AT", descending: true},
+	model.TitleSortName:          {expression: "TITLE.NAME COLLATE NOCASE", descending: false},
+	model.TitleSortUpdatedAt:     {expression: "TITLE.UPDATED_AT", descending: true},
+	model.TitleSortRelevance:     {expression: "SEARCH_RANK", descending: false},
+	model.TitleSortBookCount:     {expression: "TITLE.BOOK_COUNT", descending: true},
+	model.TitleSortPageCount:     {expression: "(SELECT COALESCE(SUM(BOOK.PAGE_COUNT), 0) FROM BOOK WHERE BOOK.TITLE_ID = TITLE.ID)", descending: true},
+	model.TitleSortFavoriteCount: {expression: "(SELECT COUNT(*) FROM PAGE WHERE PAGE.TITLE_ID = TITLE.ID AND PAGE.FAVORITE = 1)", descending: true},
+	// Titles which were never read have an empty key so that they come after read titles by default
+	model.TitleSortReadAt: {expression: "COALESCE(TITLE.READ_AT, '')", descending: true},
+}
+
+// titleRandomPrime is the modulus of the seeded shuffle, IDs and multipliers below it keep products within 64 bits
+const titleRandomPrime = 2147483647
+
+// buildTitleRandomSort shuffles titles by mapping their IDs with an affine function modulo a prime,
+// which gives every title a distinct key that stays the same for the same seed
+func buildTitleRandomSort(seed string) titleSort {
+	hash := fnv.New64a()
+	hash.Write([]byte(seed))
+	// Mix the bits of the hash so that similar seeds give unrelated shuffles
+	sum := hash.Sum64()
+	sum ^= sum >> 33
+	sum *= 0xff51afd7ed558ccd
+	sum ^= sum >> 33
+	sum *= 0xc4ceb9fe1a85ec53
+	sum ^= sum >> 33
+	multiplier := sum%(titleRandomPrime-1) + 1
+	increment := (sum >> 32) % titleRandomPrime
+
+	return titleSort{
+		expression: fmt.Sprintf("((TITLE.ID %% %d) * %d + %d) %% %d", titleRandomPrime, multiplier, increment, titleRandomPrime),
+		descending: false,
+	}
+}
+
 func (r *repositoryTitle) Find(ctx context.Context, dbOps sqlite.DBOps, titleQuery *model.TitleQuery) ([]*model.Title, error) {
-	orderBy := "CREATED_AT DESC"
-	if titleQuery.Sort == "name" {
-		orderBy = "NAME COLLATE NOCASE ASC"
-	} else if titleQuery.Sort == "updated_at" {
-		orderBy = "UPDATED_AT DESC"
+	sort, ok := titleSorts[titleQuery.Sort]
+	if titleQuery.Sort == model.TitleSortRandom {
+		sort = buildTitleRandomSort(titleQuery.Seed)
+	} else if !ok {
+		sort = titleSorts[model.TitleSortCreatedAt]
 	}
 
-	// Build SQL query
-	var query string
-	var args []interface{}
-	var err error
-	if len(titleQuery.LibraryIDs) > 0 && titleQuery.Search != "" {
-		queryString := "SELECT * FROM TITLE " +
-			"WHERE LIBRARY_ID IN (?) AND NAME LIKE ? " +
-			fmt.Sprintf("ORDER BY %s ", orderBy) +
-			"LIMIT ? " +
-			"OFFSET ?"
-		query, args, err = sqlx.In(queryString, titleQuery.LibraryIDs, "%"+titleQuery.Search+"%", titleQuery.Size, titleQuery.Page*titleQuery.Size)
-		if err != nil {
-			return nil, fmt.Errorf("rTitle - failed to bind variables for SQL query: %w", err)
-		}
-		query = dbOps.Rebind(query)
-	} else if len(titleQuery.LibraryIDs) > 0 {
-		queryString := "SELECT * FROM TITLE " +
-			"WHERE LIBRARY_ID IN (?) " +
-			fmt.Sprintf("ORDER BY %s ", orderBy) +
-			"LIMIT ? " +
-			"OFFSET ?"
-		query, args, err = sqlx.In(queryString, titleQuery.LibraryIDs, titleQuery.Size, titleQuery.Page*titleQuery.Size)
-		if err != nil {
-			return nil, fmt.Errorf("rTitle - failed to bind variables for SQL query: %w", err)
+	// Search results are ordered by relevance unless another order is requested
+	from := "FROM TITLE "
+	fromArgs := []interface{}{}
+	match := buildTitleSearchMatch(titleQuery.Search)
+	if titleQuery.Sort == model.TitleSortRelevance {
+		if match == "" {
+			sort = titleSorts[model.TitleSortCreatedAt]
+		} else {
+			from = "FROM TITLE " +
+				"JOIN (SELECT rowid AS SEARCH_TITLE_ID, rank AS SEARCH_RANK FROM TITLE_SEARCH WHERE TITLE_SEARCH MATCH ?) " +
+				"ON SEARCH_TITLE_ID = TITLE.ID "
+			fromArgs = append(fromArgs, match)
 		}
-		query = dbOps.Rebind(query)
-	} else if titleQuery.Search != "" {
-		queryString := "SELECT * FROM TITLE " +
-			"WHERE NAME LIKE ? " +
-			fmt.Sprintf("ORDER BY %s ", orderBy) +
-			"LIMIT ? " +
-			"OFFSET ?"
-		query, args, err = sqlx.In(queryString, "%"+titleQuery.Search+"%", titleQuery.Size, titleQuery.Page*titleQuery.Size)
-		if err != nil {
-			return nil, fmt.Errorf("rTitle - failed to bind variables for SQL query: %w", err)
+	}
+
+	// Build SQL query
+	where, whereArgs, err := buildTitleQueryConditions(titleQuery)
+	if err != nil {
+		return nil, fmt.Errorf("rTitle - failed to build conditions of SQL query: %w", err)
+	}
+	descending := sort.descending
+	if titleQuery.Order == model.TitleOrderAscending {
+		descending = false
+	} else if titleQuery.Order == model.TitleOrderDescending {
+		descending = true
+	}
+	direction, comparison := "ASC", ">"
+	if descending {
+		direction, comparison = "DESC", "<"
+	}
+
+	// Titles of the next page come strictly after the cursor, an offset is only used without cursor
+	pageArgs := []interface{}{titleQuery.Size}
+	offset := "OFFSET ?"
+	if titleQuery.After != nil {
+		keyset := fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND TITLE.ID %[2]s ?)) ", sort.expression, comparison)
+		if where == "" {
+			where = "WHERE " + keyset
+		} else {
+			where = where + "AND " + keyset
 		}
-		query = dbOps.Rebind(query)
+		whereArgs = append(whereArgs, titleQuery.After.Key, titleQuery.After.Key, titleQuery.After.ID)
+		offset = ""
 	} else {
-		queryString := "SELECT * FROM TITLE " +
-			fmt.Sprintf("ORDER BY %s ", orderBy) +
-			"LIMIT ? " +
-			"OFFSET ?"
-		query, args, err = sqlx.In(queryString, titleQuery.Size, titleQuery.Page*titleQuery.Size)
-		if err != nil {
-			return nil, fmt.Errorf("rTitle - failed to bind variables for SQL query: %w", err)
-		}
-		query = dbOps.Rebind(query)
+		pageArgs = append(pageArgs, titleQuery.Page*titleQuery.Size)
+	}
+	queryString := fmt.Sprintf("SELECT TITLE.*, %s AS SORT_KEY ", sort.expression) +
+		from +
+		where +
+		fmt.Sprintf("ORDER BY %s %s, TITLE.ID %s ", sort.expression, direction, direction) +
+		"LIMIT ? " +
+		offset
+	query, args, err := sqlx.In(queryString, append(append(fromArgs, whereArgs...), pageArgs...)...)
+	if err != nil {
+		return nil, fmt.Errorf("rTitle - failed to bind variables for SQL query: %w", err)
 	}
+	query = dbOps.Rebind(query)
 
 	// Execute SQL Query
 	titles := []*model.Title{}
@@ -121,36 +176,17 @@ func (r *repositoryTitle) Find(ctx context.Context, dbOps sqlite.DBOps, titleQue
 
 func (r *repositoryTitle) GetTotalFindResults(ctx context.Context, dbOps sqlite.DBOps, titleQuery *model.TitleQuery) (int, error) {
 	// Build SQL query
-	var query string
-	var args []interface{}
-	var err error
-	if len(titleQuery.LibraryIDs) > 0 && titleQuery.Search != "" {
-		queryString := "SELECT COUNT(*) FROM TITLE " +
-			"WHERE LIBRARY_ID IN (?) AND NAME LIKE ?"
-		query, args, err = sqlx.In(queryString, titleQuery.LibraryIDs, "%"+titleQuery.Search+"%")
-		if err != nil {
-			return 0, fmt.Errorf("rTitle - failed to bind variables for SQL query: %w", err)
-		}
-		query = dbOps.Rebind(query)
-	} else if len(titleQuery.LibraryIDs) > 0 {
-		queryString := "SELECT COUNT(*) FROM TITLE " +
-			"WHERE LIBRARY_ID IN (?)"
-		query, args, err = sqlx.In(queryString, titleQuery.LibraryIDs)
-		if err != nil {
-			return 0, fmt.Errorf("rTitle - failed to bind variables for SQL query: %w", err)
-		}
-		query = dbOps.Rebind(query)
-	} else if titleQuery.Search != "" {
-		queryString := "SELECT COUNT(*) FROM TITLE " +
-			"WHERE NAME LIKE ?"
-		query, args, err = sqlx.In(queryString, "%"+titleQuery.Search+"%")
-		if err != nil {
-			return 0, fmt.Errorf("rTitle - failed to bind variables for SQL query: %w", err)
-		}
-		query = dbOps.Rebind(query)
-	} else {
-		query = "SELECT COUNT(*) FROM TITLE"
+	where, whereArgs, err := buildTitleQueryConditions(titleQuery)
+	if err != nil {
+		return 0, fmt.Errorf("rTitle - failed to build conditions of SQL query: %w", err)
 	}
+	queryString := "SELECT COUNT(*) FROM TITLE " +
+		where
+	query, args, err := sqlx.In(queryString, whereArgs...)
+	if err != nil {
+		return 0, fmt.Errorf("rTitle - failed to bind variables for SQL query: %w", err)
+	}
+	query = dbOps.Rebind(query)
 
 	// Execute SQL Query
 	var count int
@@ -162,6 +198,20 @@ func (r *repositoryTitle) GetTotalFindResults(ctx context.Context, dbOps sqlite.
 	return count, nil
 }
 
+// buildTitleQueryConditions builds the WHERE clause shared by searching and counting titles from the filter of the query
+func buildTitleQueryConditions(titleQuery *model.TitleQuery) (string, []interface{}, error) {
+	if titleQuery.FilterNode == nil {
+		return "", []interface{}{}, nil
+	}
+
+	condition, args, err := compileTitleFilter(titleQuery.FilterNode)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return "WHERE " + condition + " ", args, nil
+}
+
 func (r *repositoryTitle) FindAllByLibraryID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) ([]*model.Title, error) {
 	query := "SELECT * FROM TITLE " +
 		"WHERE LIBRARY_ID = ?"
@@ -192,6 +242,32 @@ func (r *repositoryTitle) FindByID(ctx context.Context, dbOps sqlite.DBOps, titl
 	return &title, nil
 }
 
+func (r *repositoryTitle) UpdateLocation(ctx context.Context, dbOps sqlite.DBOps, titleID string, name string, url string, groupPath string) error {
+	query := "UPDATE TITLE " +
+		"SET NAME = ?, URL = ?, GROUP_PATH = ? " +
+		"WHERE ID = ?"
+
+	_, err := dbOps.ExecContext(ctx, query, name, url, groupPath, titleID)
+	if err != nil {
+		return fmt.Errorf("rTitle - failed to update NAME, URL & GROUP_PATH fields for row with given ID from table TITLE: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repositoryTitle) UpdateCreatedTime(ctx context.Context, dbOps sqlite.DBOps, titleID string, createdTime string) error {
+	query := "UPDATE TITLE " +
+		"SET CREATED_AT = ? " +
+		"WHERE ID = ?"
+
+	_, err := dbOps.ExecContext(ctx, query, createdTime, titleID)
+	if err != nil {
+		return fmt.Errorf("rTitle - failed to update CREATED_AT field for row with given ID from table TITLE: %w", err)
+	}
+
+	return nil
+}
+
 func (r *repositoryTitle) UpdateModifiedTime(ctx context.Context, dbOps sqlite.DBOps, titleID string, modTime string) error {
 	query := "UPDATE TITLE " +
 		"SET UPDATED_AT = ? " +
@@ -218,53 +294,53 @@ func (r *repositoryTitle) UpdateCoverDimension(ctx context.Context, dbOps sqlite
 	return nil
 }
 
-func (r *repositoryTitle) UpdateBookCount(ctx context.Context, dbOps sqlite.DBOps, titleID string, count int) error {
+func (r *repositoryTitle) UpdateCoverHash(ctx context.Context, dbOps sqlite.DBOps, titleID string, coverHash *string) error {
 	query := "UPDATE TITLE " +
-		"SET BOOK_COUNT = ? " +
+		"SET COVER_HASH = ? " +
 		"WHERE ID = ?"
 
-	_, err := dbOps.ExecContext(ctx, query, count, titleID)
+	_, err := dbOps.ExecContext(ctx, query, coverHash, titleID)
 	if err != nil {
-		return fmt.Errorf("rTitle - failed to update BOOK_COUNT field for row with given ID from table TITLE: %w", err)
+		return fmt.Errorf("rTitle - failed to update COVER_HASH field for row with given ID from table TITLE: %w", err)
 	}
 
 	return nil
 }
 
-func (r *repositoryTitle) UpdateUncensored(ctx context.Context, dbOps sqlite.DBOps, titleID string, uncensored int) error {
+func (r *repositoryTitle) UpdateBookCount(ctx context.Context, dbOps sqlite.DBOps, titleID string, count int) error {
 	query := "UPDATE TITLE " +
-		"SET UNCENSORED = ? " +
+		"SET BOOK_COUNT = ? " +
 		"WHERE ID = ?"
 
-	_, err := dbOps.ExecContext(ctx, query, uncensored, titleID)
+	_, err := dbOps.ExecContext(ctx, query, count, titleID)
 	if err != nil {
-		return fmt.Errorf("rTitle - failed to update UNCENSORED field for row with given ID from table TITLE: %w", err)
+		return fmt.Errorf("rTitle - failed to update BOOK_COUNT field for row with given ID from table TITLE: %w", err)
 	}
 
 	return nil
 }
 
-func (r *repositoryTitle) UpdateWaifu2x(ctx context.Context, dbOps sqlite.DBOps, titleID string, waifu2x int) error {
+func (r *repositoryTitle) UpdateLangs(ctx context.Context, dbOps sqlite.DBOps, titleID string, langs string) error {
 	query := "UPDATE TITLE " +
-		"SET WAIFU2X = ? " +
+		"SET LANGS = ? " +
 		"WHERE ID = ?"
 
-	_, err := dbOps.ExecContext(ctx, query, waifu2x, titleID)
+	_, err := dbOps.ExecContext(ctx, query, langs, titleID)
 	if err != nil {
-		return fmt.Errorf("rTitle - failed to update WAIFU2X field for row with given ID from table TITLE: %w", err)
+		return fmt.Errorf("rTitle - failed to update LANGS field for row with given ID from table TITLE: %w", err)
 	}
 
 	return nil
 }
 
-func (r *repositoryTitle) UpdateLangs(ctx context.Context, dbOps sqlite.DBOps, titleID string, langs string) error {
+func (r *repositoryTitle) UpdateReadTime(ctx context.Context, dbOps sqlite.DBOps, titleID string, readTime string) error {
 	query := "UPDATE TITLE " +
-		"SET LANGS = ? " +
+		"SET READ_AT = ? " +
 		"WHERE ID = ?"
 
-	_, err := dbOps.ExecContext(ctx, query, langs, titleID)
+	_, err := dbOps.ExecContext(ctx, query, readTime, titleID)
 	if err != nil {
-		return fmt.Errorf("rTitle - failed to update LANGS field for row with given ID from table TITLE: %w", err)
+		return fmt.Errorf("rTitle - failed to update READ_AT field for row with given ID from table TITLE: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type RepositoryTitleAuthor interface {
+	Insert(context.Context, sqlite.DBOps, int64, int64, int64) error
+	FindAllByTitleIDs(context.Context, sqlite.DBOps, []int64) ([]*model.TitleAuthor, error)
+	DeleteAllByTitleID(context.Context, sqlite.DBOps, string) error
+}
+
+type repositoryTitleAuthor struct {
+}
+
+func NewRepositoryTitleAuthor() RepositoryTitleAuthor {
+	return &repositoryTitleAuthor{}
+}
+
+func (r *repositoryTitleAuthor) Insert(ctx context.Context, dbOps sqlite.DBOps, titleID int64, authorID int64, libraryID int64) error {
+	query := "INSERT INTO TITLE_AUTHOR (TITLE_ID, AUTHOR_ID, LIBRARY_ID) " +
+		"VALUES (?, ?, ?)"
+
+	_, err := dbOps.ExecContext(ctx, query, titleID, authorID, libraryID)
+	if err != nil {
+		return fmt.Errorf("rTitleAuthor - failed to add new row to table TITLE_AUTHOR: %w", err)
+	}
+
+	return nil
+}
+
+// FindAllByTitleIDs looks up title IDs in batches, so that the number of variables stays below the limit of SQLite,
+// authors of a title are all found in the same batch
+func (r *repositoryTitleAuthor) FindAllByTitleIDs(ctx context.Context, dbOps sqlite.DBOps, titleIDs []int64) ([]*model.TitleAuthor, error) {
+	titleAuthors := []*model.TitleAuthor{}
+	for start := 0; start < len(titleIDs); start += bulkInsertMaxVariables {
+		end := start + bulkInsertMaxVariables
+		if end > len(titleIDs) {
+			end = len(titleIDs)
+		}
+
+		queryString := "SELECT TITLE_AUTHOR.TITLE_ID, AUTHOR.NAME FROM TITLE_AUTHOR " +
+			"JOIN AUTHOR ON AUTHOR.ID = TITLE_AUTHOR.AUTHOR_ID " +
+			"WHERE TITLE_AUTHOR.TITLE_ID IN (?) " +
+			"ORDER BY AUTHOR.NAME ASC"
+		query, args, err := sqlx.In(queryString, titleIDs[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("rTitleAuthor - failed to bind variables for SQL query: %w", err)
+		}
+		query = dbOps.Rebind(query)
+
+		batch := []*model.TitleAuthor{}
+		err = dbOps.SelectContext(ctx, &batch, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("rTitleAuthor - failed to find rows with given TITLE_IDs from table TITLE_AUTHOR: %w", err)
+		}
+		titleAuthors = append(titleAuthors, batch...)
+	}
+
+	return titleAuthors, nil
+}
+
+func (r *repositoryTitleAuthor) DeleteAllByTitleID(ctx context.Context, dbOps sqlite.DBOps, titleID string) error {
+	query := "DELETE FROM TITLE_AUTHOR " +
+		"WHERE TITLE_ID = ?"
+
+	_, err := dbOps.ExecContext(ctx, query, titleID)
+	if err != nil {
+		return fmt.Errorf("rTitleAuthor - failed to delete rows with given TITLE_ID from table TITLE_AUTHOR: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type RepositoryTitleCircle interface {
+	Insert(context.Context, sqlite.DBOps, int64, int64, int64) error
+	FindAllByTitleIDs(context.Context, sqlite.DBOps, []int64) ([]*model.TitleCircle, error)
+	DeleteAllByTitleID(context.Context, sqlite.DBOps, string) error
+}
+
+type repositoryTitleCircle struct {
+}
+
+func NewRepositoryTitleCircle() RepositoryTitleCircle {
+	return &repositoryTitleCircle{}
+}
+
+func (r *repositoryTitleCircle) Insert(ctx context.Context, dbOps sqlite.DBOps, titleID int64, circleID int64, libraryID int64) error {
+	query := "INSERT INTO TITLE_CIRCLE (TITLE_ID, CIRCLE_ID, LIBRARY_ID) " +
+		"VALUES (?, ?, ?)"
+
+	_, err := dbOps.ExecContext(ctx, query, titleID, circleID, libraryID)
+	if err != nil {
+		return fmt.Errorf("rTitleCircle - failed to add new row to table TITLE_CIRCLE: %w", err)
+	}
+
+	return nil
+}
+
+// FindAllByTitleIDs looks up title IDs in batches, so that the number of variables stays below the limit of SQLite,
+// circles of a title are all found in the same batch
+func (r *repositoryTitleCircle) FindAllByTitleIDs(ctx context.Context, dbOps sqlite.DBOps, titleIDs []int64) ([]*model.TitleCircle, error) {
+	titleCircles := []*model.TitleCircle{}
+	for start := 0; start < len(titleIDs); start += bulkInsertMaxVariables {
+		end := start + bulkInsertMaxVariables
+		if end > len(titleIDs) {
+			end = len(titleIDs)
+		}
+
+		queryString := "SELECT TITLE_CIRCLE.TITLE_ID, CIRCLE.NAME FROM TITLE_CIRCLE " +
+			"JOIN CIRCLE ON CIRCLE.ID = TITLE_CIRCLE.CIRCLE_ID " +
+			"WHERE TITLE_CIRCLE.TITLE_ID IN (?) " +
+			"ORDER BY CIRCLE.NAME ASC"
+		query, args, err := sqlx.In(queryString, titleIDs[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("rTitleCircle - failed to bind variables for SQL query: %w", err)
+		}
+		query = dbOps.Rebind(query)
+
+		batch := []*model.TitleCircle{}
+		err = dbOps.SelectContext(ctx, &batch, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("rTitleCircle - failed to find rows with given TITLE_IDs from table TITLE_CIRCLE: %w", err)
+		}
+		titleCircles = append(titleCircles, batch...)
+	}
+
+	return titleCircles, nil
+}
+
+func (r *repositoryTitleCircle) DeleteAllByTitleID(ctx context.Context, dbOps sqlite.DBOps, titleID string) error {
+	query := "DELETE FROM TITLE_CIRCLE " +
+		"WHERE TITLE_ID = ?"
+
+	_, err := dbOps.ExecContext(ctx, query, titleID)
+	if err != nil {
+		return fmt.Errorf("rTitleCircle - failed to delete rows with given TITLE_ID from table TITLE_CIRCLE: %w", err)
+	}
+
+	return nil
+}
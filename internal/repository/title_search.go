@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+)
+
+type RepositoryTitleSearch interface {
+	Refresh(context.Context, sqlite.DBOps, string) error
+	DeleteByTitleID(context.Context, sqlite.DBOps, string) error
+	DeleteAllByLibraryID(context.Context, sqlite.DBOps, string) error
+}
+
+type repositoryTitleSearch struct {
+}
+
+func NewRepositoryTitleSearch() RepositoryTitleSearch {
+	return &repositoryTitleSearch{}
+}
+
+// Refresh rebuilds the full-text search entry of a title from its current name, books and tags
+func (r *repositoryTitleSearch) Refresh(ctx context.Context, dbOps sqlite.DBOps, titleID string) error {
+	err := r.DeleteByTitleID(ctx, dbOps, titleID)
+	if err != nil {
+		return err
+	}
+
+	query := "INSERT INTO TITLE_SEARCH (rowid, NAME, BOOK_NAMES, METADATA, LIBRARY_ID) " +
+		"SELECT TITLE.ID, TITLE.NAME, " +
+		"COALESCE((SELECT group_concat(BOOK.NAME, ' ') FROM BOOK WHERE BOOK.TITLE_ID = TITLE.ID), ''), " +
+		"TITLE.GROUP_PATH || ' ' || TITLE.LANGS || ' ' || COALESCE((SELECT group_concat(TAG.NAME, ' ') FROM TITLE_TAG JOIN TAG ON TAG.ID = TITLE_TAG.TAG_ID WHERE TITLE_TAG.TITLE_ID = TITLE.ID), ''), " +
+		"TITLE.LIBRARY_ID " +
+		"FROM TITLE " +
+		"WHERE TITLE.ID = ?"
+
+	_, err = dbOps.ExecContext(ctx, query, titleID)
+	if err != nil {
+		return fmt.Errorf("rTitleSearch - failed to add row with given title ID to table TITLE_SEARCH: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repositoryTitleSearch) DeleteByTitleID(ctx context.Context, dbOps sqlite.DBOps, titleID string) error {
+	query := "DELETE FROM TITLE_SEARCH " +
+		"WHERE rowid = ?"
+
+	_, err := dbOps.ExecContext(ctx, query, titleID)
+	if err != nil {
+		return fmt.Errorf("rTitleSearch - failed to delete row with given title ID from table TITLE_SEARCH: %w", err)
+	}
+
+	return nil
+}
+
+func (r *repositoryTitleSearch) DeleteAllByLibraryID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) error {
+	// Columns of FTS5 tables have no type affinity, hence the ID is compared as an integer explicitly
+	query := "DELETE FROM TITLE_SEARCH " +
+		"WHERE LIBRARY_ID = CAST(? AS INTEGER)"
+
+	_, err := dbOps.ExecContext(ctx, query, libraryID)
+	if err != nil {
+		return fmt.Errorf("rTitleSearch - failed to delete rows with given library ID from table TITLE_SEARCH: %w", err)
+	}
+
+	return nil
+}
+
+// buildTitleSearchMatch turns a search text into an FTS5 query where every word has to match
+// the start of a word in any order, words are quoted so that FTS5 syntax in them is taken literally
+func buildTitleSearchMatch(search string) string {
+	terms := []string{}
+	for _, word := range strings.Fields(search) {
+		terms = append(terms, "\""+strings.ReplaceAll(word, "\"", "\"\"")+"\"*")
+	}
+
+	return strings.Join(terms, " ")
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/jmoiron/sqlx"
+)
+
+type RepositoryTitleTag interface {
+	Insert(context.Context, sqlite.DBOps, int64, int64, int64) error
+	FindAllByTitleIDs(context.Context, sqlite.DBOps, []int64) ([]*model.TitleTag, error)
+	DeleteAllByTitleID(context.Context, sqlite.DBOps, string) error
+}
+
+type repositoryTitleTag struct {
+}
+
+func NewRepositoryTitleTag() RepositoryTitleTag {
+	return &repositoryTitleTag{}
+}
+
+func (r *repositoryTitleTag) Insert(ctx context.Context, dbOps sqlite.DBOps, titleID int64, tagID int64, libraryID int64) error {
+	query := "INSERT INTO TITLE_TAG (TITLE_ID, TAG_ID, LIBRARY_ID) " +
+		"VALUES (?, ?, ?)"
+
+	_, err := dbOps.ExecContext(ctx, query, titleID, tagID, libraryID)
+	if err != nil {
+		return fmt.Errorf("rTitleTag - failed to add new row to table TITLE_TAG: %w", err)
+	}
+
+	return nil
+}
+
+// FindAllByTitleIDs looks up title IDs in batches, so that the number of variables stays below the limit of SQLite,
+// tags of a title are all found in the same batch
+func (r *repositoryTitleTag) FindAllByTitleIDs(ctx context.Context, dbOps sqlite.DBOps, titleIDs []int64) ([]*model.TitleTag, error) {
+	titleTags := []*model.TitleTag{}
+	for start := 0; start < len(titleIDs); start += bulkInsertMaxVariables {
+		end := start + bulkInsertMaxVariables
+		if end > len(titleIDs) {
+			end = len(titleIDs)
+		}
+
+		queryString := "SELECT TITLE_TAG.TITLE_ID, TAG.NAME FROM TITLE_TAG " +
+			"JOIN TAG ON TAG.ID = TITLE_TAG.TAG_ID " +
+			"WHERE TITLE_TAG.TITLE_ID IN (?) " +
+			"ORDER BY TAG.NAME ASC"
+		query, args, err := sqlx.In(queryString, titleIDs[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("rTitleTag - failed to bind variables for SQL query: %w", err)
+		}
+		query = dbOps.Rebind(query)
+
+		batch := []*model.TitleTag{}
+		err = dbOps.SelectContext(ctx, &batch, query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("rTitleTag - failed to find rows with given TITLE_IDs from table TITLE_TAG: %w", err)
+		}
+		titleTags = append(titleTags, batch...)
+	}
+
+	return titleTags, nil
+}
+
+func (r *repositoryTitleTag) DeleteAllByTitleID(ctx context.Context, dbOps sqlite.DBOps, titleID string) error {
+	query := "DELETE FROM TITLE_TAG " +
+		"WHERE TITLE_ID = ?"
+
+	_, err := dbOps.ExecContext(ctx, query, titleID)
+	if err != nil {
+		return fmt.Errorf("rTitleTag - failed to delete rows with given TITLE_ID from table TITLE_TAG: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	httpServer "github.com/imouto1994/yume/internal/infra/http"
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/imouto1994/yume/internal/service"
+)
+
+type HandlerAdmin struct {
+	db              sqlite.DB
+	serviceBackup   service.ServiceBackup
+	serviceUserData service.ServiceUserData
+}
+
+func NewHandlerAdmin(db sqlite.DB, sBackup service.ServiceBackup, sUserData service.ServiceUserData) *HandlerAdmin {
+	return &HandlerAdmin{
+		db:              db,
+		serviceBackup:   sBackup,
+		serviceUserData: sUserData,
+	}
+}
+
+func (h *HandlerAdmin) InitializeRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/backup", h.handleGetBackups())
+	r.Post("/backup", h.handleCreateBackup())
+	r.Get("/export", h.handleExportUserData())
+	r.Post("/import", h.handleImportUserData())
+
+	return r
+}
+
+func (h *HandlerAdmin) handleGetBackups() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		backups, err := h.serviceBackup.GetBackups()
+		if err != nil {
+			httpServer.RespondError(w, "failed to get backups", fmt.Errorf("hAdmin - failed to use service Backup to get backups: %w", err))
+			return
+		}
+
+		httpServer.RespondJSON(w, 200, backups)
+	}
+}
+
+func (h *HandlerAdmin) handleCreateBackup() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		backup, err := h.serviceBackup.CreateBackup(ctx, h.db)
+		if err != nil {
+			httpServer.RespondError(w, "failed to back up database", fmt.Errorf("hAdmin - failed to use service Backup to create backup: %w", err))
+			return
+		}
+
+		httpServer.RespondJSON(w, 200, backup)
+	}
+}
+
+func (h *HandlerAdmin) handleExportUserData() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		userData, err := h.serviceUserData.ExportUserData(ctx, h.db)
+		if err != nil {
+			httpServer.RespondError(w, "failed to export user data", fmt.Errorf("hAdmin - failed to use service UserData to export user data: %w", err))
+			return
+		}
+
+		w.Header().Set("Content-Disposition", `attachment; filename="yume-user-data.json"`)
+		httpServer.RespondJSON(w, 200, userData)
+	}
+}
+
+func (h *HandlerAdmin) handleImportUserData() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var body model.UserData
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			httpServer.RespondBadRequestError(w, "request body is not in JSON format", fmt.Errorf("hAdmin - request body is not in JSON format for importing user data: %w ", err))
+			return
+		}
+
+		result, err := h.serviceUserData.ImportUserData(ctx, h.db, &body)
+		if err != nil {
+			httpServer.RespondError(w, "failed to import user data", fmt.Errorf("hAdmin - failed to use service UserData to import user data: %w", err))
+			return
+		}
+
+		httpServer.RespondJSON(w, 200, result)
+	}
+}
@@ -0,0 +1,87 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	httpServer "github.com/imouto1994/yume/internal/infra/http"
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/imouto1994/yume/internal/service"
+)
+
+type HandlerAuthor struct {
+	db            sqlite.DB
+	serviceAuthor service.ServiceAuthor
+	serviceTitle  service.ServiceTitle
+}
+
+func NewHandlerAuthor(db sqlite.DB, sAuthor service.ServiceAuthor, sTitle service.ServiceTitle) *HandlerAuthor {
+	return &HandlerAuthor{
+		db:            db,
+		serviceAuthor: sAuthor,
+		serviceTitle:  sTitle,
+	}
+}
+
+func (h *HandlerAuthor) InitializeRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/", h.handleGetAuthors())
+	r.Get("/{authorID}/titles", h.handleGetAuthorTitles())
+
+	return r
+}
+
+func (h *HandlerAuthor) handleGetAuthors() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		authors, err := h.serviceAuthor.GetAuthors(ctx, h.db)
+		if err != nil {
+			httpServer.RespondError(w, "failed to get authors", fmt.Errorf("hAuthor - failed to use service Author to get all authors: %w", err))
+			return
+		}
+
+		httpServer.RespondJSON(w, 200, authors)
+	}
+}
+
+func (h *HandlerAuthor) handleGetAuthorTitles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		authorID := chi.URLParam(r, "authorID")
+		queryValues := r.URL.Query()
+
+		pageNumber, sizeNumber, err := parsePageQuery(queryValues)
+		if err != nil {
+			httpServer.RespondBadRequestError(w, "page or size number is invalid", fmt.Errorf("hAuthor - invalid page query for getting author titles: %w", err))
+			return
+		}
+
+		author, err := h.serviceAuthor.GetAuthorByID(ctx, h.db, authorID)
+		if err != nil {
+			httpServer.RespondError(w, "failed to get author", fmt.Errorf("hAuthor - failed to use service Author to get author by ID: %w", err))
+			return
+		}
+
+		titleQuery := &model.TitleQuery{
+			LibraryIDs: queryValues["library_id"],
+			Page:       pageNumber,
+			Size:       sizeNumber,
+			Sort:       queryValues.Get("sort"),
+			Order:      queryValues.Get("order"),
+			Seed:       queryValues.Get("seed"),
+			AuthorIDs:  []string{fmt.Sprintf("%d", author.ID)},
+		}
+
+		titles, err := h.serviceTitle.SearchTitles(ctx, h.db, titleQuery)
+		if err != nil {
+			httpServer.RespondError(w, "failed to get author titles", fmt.Errorf("hAuthor - failed to use service Title to search titles of author: %w", err))
+			return
+		}
+
+		httpServer.RespondJSON(w, 200, titles)
+	}
+}
@@ -4,10 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
@@ -173,33 +170,12 @@ func (h *HandlerBook) handleUpdateBookPageFavorite() http.HandlerFunc {
 			return
 		}
 
+		// Keep favorites in the sidecar of the book so that they survive rescans
 		go func() {
-			ctx := context.Background()
-
-			book, err := h.serviceBook.GetBookByID(ctx, h.db, bookID)
-			if err != nil {
-				zap.L().Error("hBook - failed to get book data to save book metadata", zap.Error(err))
-				return
-			}
-
-			pages, err := h.serviceBook.GetBookPages(ctx, h.db, bookID)
+			err := h.serviceBook.SaveBookSidecar(context.Background(), h.db, bookID)
 			if err != nil {
-				zap.L().Error("hBook - failed to get updated pages data to save book metadata", zap.Error(err))
-				return
+				zap.L().Error("hBook - failed to use service Book to save book sidecar", zap.Error(err))
 			}
-
-			favoriteIndices := []int{}
-			for index, page := range pages {
-				if page.Favorite == 1 {
-					favoriteIndices = append(favoriteIndices, index)
-				}
-			}
-
-			titleFolderPath := filepath.Dir(book.URL)
-			metadataFilePath := filepath.Join(titleFolderPath, fmt.Sprintf("%s.json", book.Name))
-
-			file, _ := json.MarshalIndent(favoriteIndices, "", " ")
-			ioutil.WriteFile(metadataFilePath, file, os.ModePerm)
 		}()
 
 		resp := response{}
@@ -0,0 +1,87 @@
+package route
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-playground/validator"
+	httpServer "github.com/imouto1994/yume/internal/infra/http"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/imouto1994/yume/internal/service"
+)
+
+type HandlerConvention struct {
+	serviceConvention service.ServiceConvention
+	validate          *validator.Validate
+}
+
+func NewHandlerConvention(sConvention service.ServiceConvention, v *validator.Validate) *HandlerConvention {
+	return &HandlerConvention{
+		serviceConvention: sConvention,
+		validate:          v,
+	}
+}
+
+func (h *HandlerConvention) InitializeRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/", h.handleGetConventions())
+	r.Post("/{conventionName}/test", h.handleTestConvention())
+
+	return r
+}
+
+func (h *HandlerConvention) handleGetConventions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		httpServer.RespondJSON(w, 200, h.serviceConvention.GetConventions())
+	}
+}
+
+func (h *HandlerConvention) handleTestConvention() http.HandlerFunc {
+	type request struct {
+		Title string `json:"title"`
+		Book  string `json:"book"`
+	}
+
+	type response struct {
+		Title *model.TitleNameInfo `json:"title,omitempty"`
+		Book  *model.BookNameInfo  `json:"book,omitempty"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		conventionName := chi.URLParam(r, "conventionName")
+
+		var body request
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			httpServer.RespondBadRequestError(w, "request body is not in JSON format", fmt.Errorf("hConvention - request body is not in JSON format for testing convention: %w ", err))
+			return
+		}
+
+		err = h.validate.Struct(body)
+		if err != nil {
+			httpServer.RespondBadRequestError(w, "request body is invalid", fmt.Errorf("hConvention - request JSON body is not valid for testing convention: %w ", err))
+			return
+		}
+
+		var resp response
+		if body.Title != "" {
+			resp.Title, err = h.serviceConvention.ParseTitleName(conventionName, body.Title)
+			if err != nil {
+				httpServer.RespondError(w, "failed to parse title name", fmt.Errorf("hConvention - failed to use service Convention to parse title name: %w", err))
+				return
+			}
+		}
+		if body.Book != "" {
+			resp.Book, err = h.serviceConvention.ParseBookName(conventionName, body.Book)
+			if err != nil {
+				httpServer.RespondError(w, "failed to parse book name", fmt.Errorf("hConvention - failed to use service Convention to parse book name: %w", err))
+				return
+			}
+		}
+
+		httpServer.RespondJSON(w, 200, resp)
+	}
+}
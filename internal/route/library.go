@@ -1,10 +1,10 @@
 package route
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -16,17 +16,25 @@ import (
 	"go.uber.org/zap"
 )
 
+const scanEventsKeepAliveInterval = 15 * time.Second
+
 type HandlerLibrary struct {
-	db             sqlite.DB
-	serviceLibrary service.ServiceLibrary
-	validate       *validator.Validate
+	db                   sqlite.DB
+	serviceLibrary       service.ServiceLibrary
+	serviceScanProgress  service.ServiceScanProgress
+	serviceScanJob       service.ServiceScanJob
+	serviceScanScheduler service.ServiceScanScheduler
+	validate             *validator.Validate
 }
 
-func NewHandlerLibrary(db sqlite.DB, v *validator.Validate, s service.ServiceLibrary) *HandlerLibrary {
+func NewHandlerLibrary(db sqlite.DB, v *validator.Validate, s service.ServiceLibrary, sScanProgress service.ServiceScanProgress, sScanJob service.ServiceScanJob, sScanScheduler service.ServiceScanScheduler) *HandlerLibrary {
 	return &HandlerLibrary{
-		db:             db,
-		serviceLibrary: s,
-		validate:       v,
+		db:                   db,
+		serviceLibrary:       s,
+		serviceScanProgress:  sScanProgress,
+		serviceScanJob:       sScanJob,
+		serviceScanScheduler: sScanScheduler,
+		validate:             v,
 	}
 }
 
@@ -35,16 +43,27 @@ func (h *HandlerLibrary) InitializeRoutes() http.Handler {
 
 	r.Post("/", h.handleCreateLibrary())
 	r.Get("/", h.handleGetLibraries())
+	r.Get("/{libraryID}", h.handleGetLibrary())
+	r.Patch("/{libraryID}", h.handleUpdateLibrary())
 	r.Delete("/{libraryID}", h.handleDeleteLibrary())
 	r.Post("/{libraryID}/scan", h.handleScanLibrary())
+	r.Get("/{libraryID}/scan/events", h.handleStreamScanEvents())
+	r.Get("/{libraryID}/scan/errors", h.handleGetScanErrors())
 
 	return r
 }
 
 func (h *HandlerLibrary) handleCreateLibrary() http.HandlerFunc {
 	type request struct {
-		Name string `json:"name" validate:"required"`
-		Root string `json:"root" validate:"required"`
+		Name            string   `json:"name" validate:"required"`
+		Root            string   `json:"root" validate:"required"`
+		ScanWorkers     *int     `json:"scan_workers" validate:"omitempty,min=1"`
+		ScanDepth       *int     `json:"scan_depth" validate:"omitempty,min=1,max=8"`
+		Convention      *string  `json:"convention"`
+		IncludePatterns []string `json:"include_patterns"`
+		ExcludePatterns []string `json:"exclude_patterns"`
+		IncludeHidden   *bool    `json:"include_hidden"`
+		ScanSchedule    *string  `json:"scan_schedule"`
 	}
 
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -64,8 +83,15 @@ func (h *HandlerLibrary) handleCreateLibrary() http.HandlerFunc {
 		}
 
 		newLibrary := &model.Library{
-			Name: body.Name,
-			Root: body.Root,
+			Name:            body.Name,
+			Root:            body.Root,
+			ScanWorkers:     body.ScanWorkers,
+			ScanDepth:       body.ScanDepth,
+			Convention:      body.Convention,
+			IncludePatterns: body.IncludePatterns,
+			ExcludePatterns: body.ExcludePatterns,
+			IncludeHidden:   body.IncludeHidden,
+			ScanSchedule:    body.ScanSchedule,
 		}
 
 		err = h.serviceLibrary.CreateLibrary(ctx, h.db, newLibrary)
@@ -74,6 +100,12 @@ func (h *HandlerLibrary) handleCreateLibrary() http.HandlerFunc {
 			return
 		}
 
+		// The library is already stored, hence failing to schedule its scans does not fail the request
+		err = h.serviceScanScheduler.Schedule(h.db, newLibrary)
+		if err != nil {
+			zap.L().Error("hLibrary - failed to use service ScanScheduler to schedule library scans", zap.Int64("libraryID", newLibrary.ID), zap.Error(err))
+		}
+
 		httpServer.RespondJSON(w, 200, newLibrary)
 	}
 }
@@ -96,6 +128,104 @@ func (h *HandlerLibrary) handleGetLibraries() http.HandlerFunc {
 	}
 }
 
+func (h *HandlerLibrary) handleGetLibrary() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		libraryID := chi.URLParam(r, "libraryID")
+
+		libraryDetail, err := h.serviceLibrary.GetLibraryDetailByID(ctx, h.db, libraryID)
+		if err != nil {
+			httpServer.RespondError(w, "failed to get library", fmt.Errorf("hLibrary - failed to use service Library to get library: %w", err))
+			return
+		}
+
+		httpServer.RespondJSON(w, 200, libraryDetail)
+	}
+}
+
+func (h *HandlerLibrary) handleUpdateLibrary() http.HandlerFunc {
+	type request struct {
+		Name            *string   `json:"name" validate:"omitempty,min=1"`
+		Root            *string   `json:"root" validate:"omitempty,min=1"`
+		ScanWorkers     *int      `json:"scan_workers" validate:"omitempty,min=1"`
+		ScanDepth       *int      `json:"scan_depth" validate:"omitempty,min=1,max=8"`
+		Convention      *string   `json:"convention"`
+		IncludePatterns *[]string `json:"include_patterns"`
+		ExcludePatterns *[]string `json:"exclude_patterns"`
+		IncludeHidden   *bool     `json:"include_hidden"`
+		ScanSchedule    *string   `json:"scan_schedule"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		libraryID := chi.URLParam(r, "libraryID")
+
+		var body request
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			httpServer.RespondBadRequestError(w, "request body is not in JSON format", fmt.Errorf("hLibrary - request body is not in JSON format for updating library: %w ", err))
+			return
+		}
+
+		err = h.validate.Struct(body)
+		if err != nil {
+			httpServer.RespondBadRequestError(w, "request body is invalid", fmt.Errorf("hLibrary - request JSON body is not valid for updating library: %w ", err))
+			return
+		}
+
+		library, err := h.serviceLibrary.GetLibraryByID(ctx, h.db, libraryID)
+		if err != nil {
+			httpServer.RespondError(w, "failed to get library", err)
+			return
+		}
+
+		// Only fields present in the request body are updated,
+		// an empty convention falls back to the default one, empty pattern lists clear the patterns
+		// and an empty scan schedule turns scheduled scans off
+		if body.Name != nil {
+			library.Name = *body.Name
+		}
+		if body.Root != nil {
+			library.Root = *body.Root
+		}
+		if body.ScanWorkers != nil {
+			library.ScanWorkers = body.ScanWorkers
+		}
+		if body.ScanDepth != nil {
+			library.ScanDepth = body.ScanDepth
+		}
+		if body.Convention != nil {
+			library.Convention = body.Convention
+		}
+		if body.IncludePatterns != nil {
+			library.IncludePatterns = *body.IncludePatterns
+		}
+		if body.ExcludePatterns != nil {
+			library.ExcludePatterns = *body.ExcludePatterns
+		}
+		if body.IncludeHidden != nil {
+			library.IncludeHidden = body.IncludeHidden
+		}
+		if body.ScanSchedule != nil {
+			library.ScanSchedule = body.ScanSchedule
+		}
+
+		err = h.serviceLibrary.UpdateLibrary(ctx, h.db, library)
+		if err != nil {
+			httpServer.RespondError(w, "failed to update library", fmt.Errorf("hLibrary - failed to use service Library to update library: %w", err))
+			return
+		}
+
+		// The library is already stored, hence failing to schedule its scans does not fail the request
+		err = h.serviceScanScheduler.Schedule(h.db, library)
+		if err != nil {
+			zap.L().Error("hLibrary - failed to use service ScanScheduler to schedule library scans", zap.Int64("libraryID", library.ID), zap.Error(err))
+		}
+
+		httpServer.RespondJSON(w, 200, library)
+	}
+}
+
 func (h *HandlerLibrary) handleDeleteLibrary() http.HandlerFunc {
 	type response struct{}
 
@@ -122,14 +252,17 @@ func (h *HandlerLibrary) handleDeleteLibrary() http.HandlerFunc {
 			return
 		}
 
+		libraryIDInt, err := strconv.ParseInt(libraryID, 10, 64)
+		if err == nil {
+			h.serviceScanScheduler.Unschedule(libraryIDInt)
+		}
+
 		resp := response{}
 		httpServer.RespondJSON(w, 200, resp)
 	}
 }
 
 func (h *HandlerLibrary) handleScanLibrary() http.HandlerFunc {
-	type response struct{}
-
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		libraryID := chi.URLParam(r, "libraryID")
@@ -140,29 +273,92 @@ func (h *HandlerLibrary) handleScanLibrary() http.HandlerFunc {
 			return
 		}
 
-		go func() {
-			start := time.Now()
-			ctx := context.Background()
-			tx, err := h.db.BeginTxx(ctx, nil)
+		dryRun := false
+		dryRunString := r.URL.Query().Get("dry_run")
+		if dryRunString != "" {
+			dryRun, err = strconv.ParseBool(dryRunString)
 			if err != nil {
-				zap.L().Error("hLibrary - failed to begin SQL transaction for scanning library", zap.Error(err))
+				httpServer.RespondBadRequestError(w, "dry run flag is invalid", fmt.Errorf("hLibrary - dry run query is not a boolean for scanning library: %w", err))
 				return
 			}
-			err = h.serviceLibrary.ScanLibrary(ctx, tx, library)
+		}
+
+		// Only compute what the scan would change without touching the DB
+		if dryRun {
+			scanDiff, err := h.serviceLibrary.DiffLibrary(ctx, h.db, library)
 			if err != nil {
-				zap.L().Error("hLibrary - failed to scan and update library", zap.Error(err))
-				tx.Rollback()
+				httpServer.RespondError(w, "failed to compute library scan diff", fmt.Errorf("hLibrary - failed to use service Library to compute library scan diff: %w", err))
 				return
 			}
-			err = tx.Commit()
+
+			httpServer.RespondJSON(w, 200, scanDiff)
+			return
+		}
+
+		job, started, err := h.serviceScanJob.StartScan(h.db, library)
+		if err != nil {
+			httpServer.RespondError(w, "failed to start library scan", fmt.Errorf("hLibrary - failed to use service ScanJob to start library scan: %w", err))
+			return
+		}
+		if !started {
+			zap.L().Info("hLibrary - library scan is already running", zap.String("jobID", job.ID))
+		}
+
+		httpServer.RespondJSON(w, 200, job)
+	}
+}
+
+func (h *HandlerLibrary) handleGetScanErrors() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		libraryID := chi.URLParam(r, "libraryID")
+
+		scanErrors, err := h.serviceLibrary.GetLibraryScanErrors(ctx, h.db, libraryID)
+		if err != nil {
+			httpServer.RespondError(w, "failed to get library scan errors", fmt.Errorf("hLibrary - failed to use service Library to get library scan errors: %w", err))
+			return
+		}
+
+		httpServer.RespondJSON(w, 200, scanErrors)
+	}
+}
+
+func (h *HandlerLibrary) handleStreamScanEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		libraryID := chi.URLParam(r, "libraryID")
+
+		library, err := h.serviceLibrary.GetLibraryByID(ctx, h.db, libraryID)
+		if err != nil {
+			httpServer.RespondError(w, "failed to get library", err)
+			return
+		}
+
+		flusher, err := httpServer.PrepareEventStream(w)
+		if err != nil {
+			httpServer.RespondInternalServerError(w, "failed to stream scan events", fmt.Errorf("hLibrary - failed to prepare event stream for scan events: %w", err))
+			return
+		}
+
+		events, unsubscribe := h.serviceScanProgress.Subscribe(library.ID)
+		defer unsubscribe()
+
+		keepAliveTicker := time.NewTicker(scanEventsKeepAliveInterval)
+		defer keepAliveTicker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event := <-events:
+				err = httpServer.WriteEvent(w, flusher, event.Stage, event)
+			case <-keepAliveTicker.C:
+				err = httpServer.WriteEventComment(w, flusher, "keep-alive")
+			}
 			if err != nil {
-				zap.L().Error("hLibrary - failed to commit SQL transaction for scanning library", zap.Error(err))
+				zap.L().Warn("hLibrary - failed to write scan event to client", zap.Error(err))
+				return
 			}
-			elapsed := time.Since(start)
-			zap.L().Info("hLibrary - successfully scanned and updated library", zap.Duration("duration", elapsed))
-		}()
-
-		resp := response{}
-		httpServer.RespondJSON(w, 200, resp)
+		}
 	}
 }
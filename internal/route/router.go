@@ -10,8 +10,10 @@ import (
 
 	"github.com/imouto1994/yume/internal/infra/config"
 	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/infra/worker"
 	"github.com/imouto1994/yume/internal/repository"
 	"github.com/imouto1994/yume/internal/service"
+	"go.uber.org/zap"
 )
 
 func CreateRouter(cfg *config.Config, db sqlite.DB, v *validator.Validate) http.Handler {
@@ -21,20 +23,59 @@ func CreateRouter(cfg *config.Config, db sqlite.DB, v *validator.Validate) http.
 	repositoryBook := repository.NewRepositoryBook()
 	repositoryPage := repository.NewRepositoryPage()
 	repositoryPreview := repository.NewRepositoryPreview()
+	repositoryScanError := repository.NewRepositoryScanError()
+	repositoryLibraryScan := repository.NewRepositoryLibraryScan()
+	repositoryTag := repository.NewRepositoryTag()
+	repositoryTitleTag := repository.NewRepositoryTitleTag()
+	repositoryTitleSearch := repository.NewRepositoryTitleSearch()
+	repositoryBookTag := repository.NewRepositoryBookTag()
+	repositoryAuthor := repository.NewRepositoryAuthor()
+	repositoryCircle := repository.NewRepositoryCircle()
+	repositoryTitleAuthor := repository.NewRepositoryTitleAuthor()
+	repositoryTitleCircle := repository.NewRepositoryTitleCircle()
+
+	// Initialize worker pool shared by all library scans
+	scanWorkerPool := worker.NewPool(cfg.ScanWorkers)
 
 	// Initialize services
 	serviceImage := service.NewServiceImage()
-	serviceArchive := service.NewServiceArchive()
-	serviceScanner := service.NewServiceScanner(serviceImage, serviceArchive)
-	serviceBook := service.NewServiceBook(repositoryBook, repositoryPage, repositoryPreview, serviceArchive, serviceImage)
-	serviceTitle := service.NewServiceTitle(repositoryTitle, serviceBook)
-	serviceLibrary := service.NewServiceLibrary(repositoryLibrary, serviceScanner, serviceTitle, serviceBook)
+	serviceArchive := service.NewServiceArchive(serviceImage)
+	serviceScanProgress := service.NewServiceScanProgress()
+	serviceConvention := service.NewServiceConvention(cfg.Conventions)
+	serviceScanner := service.NewServiceScanner(serviceImage, serviceArchive, serviceConvention, serviceScanProgress, scanWorkerPool)
+	serviceSidecar := service.NewServiceSidecar()
+	serviceTag := service.NewServiceTag(repositoryTag)
+	serviceAuthor := service.NewServiceAuthor(repositoryAuthor, repositoryCircle)
+	serviceBook := service.NewServiceBook(repositoryBook, repositoryPage, repositoryPreview, repositoryBookTag, serviceArchive, serviceImage, serviceSidecar, serviceTag)
+	serviceTitle := service.NewServiceTitle(repositoryTitle, repositoryTitleSearch, repositoryTitleTag, repositoryTitleAuthor, repositoryTitleCircle, serviceBook, serviceTag, serviceAuthor)
+	serviceLibrary := service.NewServiceLibrary(repositoryLibrary, repositoryLibraryScan, repositoryScanError, serviceScanner, serviceConvention, serviceScanProgress, serviceTitle, serviceBook, scanWorkerPool)
 	serviceSubtitle := service.NewServiceSubtitle(serviceLibrary, serviceBook, serviceTitle)
+	serviceScanJob := service.NewServiceScanJob(serviceLibrary, serviceScanProgress)
+	serviceScanScheduler := service.NewServiceScanScheduler(serviceLibrary, serviceScanJob)
+	serviceBackup := service.NewServiceBackup(cfg.Backup, cfg.Database)
+	serviceUserData := service.NewServiceUserData(repositoryLibrary, repositoryTitle, repositoryPage, serviceBook, serviceArchive)
+
+	// Start scheduled scans of libraries
+	err := serviceScanScheduler.Start(db)
+	if err != nil {
+		zap.L().Error("failed to start scheduled library scans", zap.Error(err))
+	}
+
+	// Start scheduled backups of database
+	err = serviceBackup.StartSchedule(db)
+	if err != nil {
+		zap.L().Error("failed to start scheduled backups", zap.Error(err))
+	}
 
 	// Initialize handlers
-	handlerLibrary := NewHandlerLibrary(db, v, serviceLibrary)
+	handlerLibrary := NewHandlerLibrary(db, v, serviceLibrary, serviceScanProgress, serviceScanJob, serviceScanScheduler)
 	hanlderBook := NewHandlerBook(db, serviceBook, v)
 	handlerTitle := NewHandlerTitle(db, serviceTitle, serviceBook, serviceSubtitle, v)
+	handlerScan := NewHandlerScan(serviceScanJob)
+	handlerConvention := NewHandlerConvention(serviceConvention, v)
+	handlerTag := NewHandlerTag(db, serviceTag)
+	handlerAuthor := NewHandlerAuthor(db, serviceAuthor, serviceTitle)
+	handlerAdmin := NewHandlerAdmin(db, serviceBackup, serviceUserData)
 
 	r := chi.NewRouter()
 
@@ -42,7 +83,7 @@ func CreateRouter(cfg *config.Config, db sqlite.DB, v *validator.Validate) http.
 	r.Use(middleware.Recoverer)
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
 		ExposedHeaders:   []string{"*"},
 		AllowCredentials: true,
@@ -52,6 +93,11 @@ func CreateRouter(cfg *config.Config, db sqlite.DB, v *validator.Validate) http.
 	r.Mount("/api/library", handlerLibrary.InitializeRoutes())
 	r.Mount("/api/title", handlerTitle.InitializeRoutes())
 	r.Mount("/api/book", hanlderBook.InitializeRoutes())
+	r.Mount("/api/scan", handlerScan.InitializeRoutes())
+	r.Mount("/api/convention", handlerConvention.InitializeRoutes())
+	r.Mount("/api/tag", handlerTag.InitializeRoutes())
+	r.Mount("/api/author", handlerAuthor.InitializeRoutes())
+	r.Mount("/api/admin", handlerAdmin.InitializeRoutes())
 
 	return r
 }
@@ -0,0 +1,57 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	httpServer "github.com/imouto1994/yume/internal/infra/http"
+	"github.com/imouto1994/yume/internal/service"
+)
+
+type HandlerScan struct {
+	serviceScanJob service.ServiceScanJob
+}
+
+func NewHandlerScan(sScanJob service.ServiceScanJob) *HandlerScan {
+	return &HandlerScan{
+		serviceScanJob: sScanJob,
+	}
+}
+
+func (h *HandlerScan) InitializeRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/{jobID}", h.handleGetScanJob())
+	r.Delete("/{jobID}", h.handleCancelScanJob())
+
+	return r
+}
+
+func (h *HandlerScan) handleGetScanJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobID := chi.URLParam(r, "jobID")
+
+		job, err := h.serviceScanJob.GetScanJob(jobID)
+		if err != nil {
+			httpServer.RespondError(w, "failed to get scan job", fmt.Errorf("hScan - failed to use service ScanJob to get scan job: %w", err))
+			return
+		}
+
+		httpServer.RespondJSON(w, 200, job)
+	}
+}
+
+func (h *HandlerScan) handleCancelScanJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobID := chi.URLParam(r, "jobID")
+
+		job, err := h.serviceScanJob.CancelScan(jobID)
+		if err != nil {
+			httpServer.RespondError(w, "failed to cancel scan job", fmt.Errorf("hScan - failed to use service ScanJob to cancel scan job: %w", err))
+			return
+		}
+
+		httpServer.RespondJSON(w, 200, job)
+	}
+}
@@ -0,0 +1,45 @@
+package route
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	httpServer "github.com/imouto1994/yume/internal/infra/http"
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/service"
+)
+
+type HandlerTag struct {
+	db         sqlite.DB
+	serviceTag service.ServiceTag
+}
+
+func NewHandlerTag(db sqlite.DB, sTag service.ServiceTag) *HandlerTag {
+	return &HandlerTag{
+		db:         db,
+		serviceTag: sTag,
+	}
+}
+
+func (h *HandlerTag) InitializeRoutes() http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/", h.handleGetTags())
+
+	return r
+}
+
+func (h *HandlerTag) handleGetTags() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		tags, err := h.serviceTag.GetTags(ctx, h.db)
+		if err != nil {
+			httpServer.RespondError(w, "failed to get tags", fmt.Errorf("hTag - failed to use service Tag to get all tags: %w", err))
+			return
+		}
+
+		httpServer.RespondJSON(w, 200, tags)
+	}
+}
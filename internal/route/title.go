@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/validator"
@@ -40,6 +42,7 @@ func (h *HandlerTitle) InitializeRoutes() http.Handler {
 	r.Get("/{titleID}", h.handleGetTitleByID())
 	r.Get("/{titleID}/cover", h.handleGetTitleCoverFile())
 	r.Get("/{titleID}/books", h.handleGetTitleBooks())
+	r.Put("/{titleID}/read", h.handleUpdateTitleReadTime())
 	r.Post("/subtitle", h.handleCreateSubtitle())
 
 	return r
@@ -51,26 +54,10 @@ func (h *HandlerTitle) handleGetTitles() http.HandlerFunc {
 
 		queryValues := r.URL.Query()
 
-		var err error
-
-		pageString := queryValues.Get("page")
-		pageNumber := 0
-		if pageString != "" {
-			pageNumber, err = strconv.Atoi(pageString)
-			if err != nil {
-				httpServer.RespondBadRequestError(w, "page number is invalid", fmt.Errorf("hTitle - page number query is not number for searching titles: %w", err))
-				return
-			}
-		}
-
-		sizeString := queryValues.Get("size")
-		sizeNumber := 24
-		if sizeString != "" {
-			sizeNumber, err = strconv.Atoi(sizeString)
-			if err != nil {
-				httpServer.RespondBadRequestError(w, "size number is invalid", fmt.Errorf("hTitle - size number query is not number for searching titles: %w", err))
-				return
-			}
+		pageNumber, sizeNumber, err := parsePageQuery(queryValues)
+		if err != nil {
+			httpServer.RespondBadRequestError(w, "page or size number is invalid", fmt.Errorf("hTitle - invalid page query for searching titles: %w", err))
+			return
 		}
 
 		titleQuery := &model.TitleQuery{
@@ -78,7 +65,24 @@ func (h *HandlerTitle) handleGetTitles() http.HandlerFunc {
 			Page:       pageNumber,
 			Size:       sizeNumber,
 			Sort:       queryValues.Get("sort"),
+			Order:      queryValues.Get("order"),
+			Seed:       queryValues.Get("seed"),
 			Search:     queryValues.Get("search"),
+			Tags:       uniqueQueryValues(queryValues["tag"]),
+			Filter:     queryValues.Get("q"),
+			Cursor:     queryValues.Get("cursor"),
+		}
+
+		// Titles are paged by cursor once the parameter is given, an empty cursor requests the first page
+		if _, ok := queryValues["cursor"]; ok {
+			titlePage, err := h.serviceTitle.SearchTitlesPage(ctx, h.db, titleQuery)
+			if err != nil {
+				httpServer.RespondError(w, "failed to search for titles with given query", fmt.Errorf("hTitle - failed to use service Title to search page of titles: %w", err))
+				return
+			}
+
+			httpServer.RespondJSON(w, 200, titlePage)
+			return
 		}
 
 		titles, err := h.serviceTitle.SearchTitles(ctx, h.db, titleQuery)
@@ -103,6 +107,8 @@ func (h *HandlerTitle) handleCountGetTitles() http.HandlerFunc {
 		titleQuery := &model.TitleQuery{
 			LibraryIDs: queryValues["library_id"],
 			Search:     queryValues.Get("search"),
+			Tags:       uniqueQueryValues(queryValues["tag"]),
+			Filter:     queryValues.Get("q"),
 		}
 
 		count, err := h.serviceTitle.CountSearchTitles(ctx, h.db, titleQuery)
@@ -148,6 +154,35 @@ func (h *HandlerTitle) handleGetTitleBooks() http.HandlerFunc {
 	}
 }
 
+func (h *HandlerTitle) handleUpdateTitleReadTime() http.HandlerFunc {
+	type response struct {
+		ReadAt string `json:"read_at"`
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		titleID := chi.URLParam(r, "titleID")
+
+		_, err := h.serviceTitle.GetTitleByID(ctx, h.db, titleID)
+		if err != nil {
+			httpServer.RespondError(w, "failed to get title", fmt.Errorf("hTitle - failed to use service Title to get title by title ID: %w", err))
+			return
+		}
+
+		readAt := time.Now().UTC().Format(time.RFC3339)
+		err = h.serviceTitle.UpdateTitleReadTime(ctx, h.db, titleID, readAt)
+		if err != nil {
+			httpServer.RespondError(w, "failed to update title read time", fmt.Errorf("hTitle - failed to use service Title to update title read time: %w", err))
+			return
+		}
+
+		resp := response{
+			ReadAt: readAt,
+		}
+		httpServer.RespondJSON(w, 200, resp)
+	}
+}
+
 func (h *HandlerTitle) handleGetTitleCoverFile() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -211,3 +246,53 @@ func (h *HandlerTitle) handleCreateSubtitle() http.HandlerFunc {
 		httpServer.RespondJSON(w, 200, resp)
 	}
 }
+
+// maxPageSize bounds the number of items of a paginated query, so that a single request cannot load a whole library
+const maxPageSize = 1000
+
+// parsePageQuery reads the page number and page size of a paginated query,
+// which are the first page and 24 items by default
+func parsePageQuery(queryValues url.Values) (int, int, error) {
+	var err error
+
+	pageString := queryValues.Get("page")
+	pageNumber := 0
+	if pageString != "" {
+		pageNumber, err = strconv.Atoi(pageString)
+		if err != nil {
+			return 0, 0, fmt.Errorf("page number query is not number: %w", err)
+		}
+		if pageNumber < 0 {
+			return 0, 0, fmt.Errorf("page number query is negative: %d", pageNumber)
+		}
+	}
+
+	sizeString := queryValues.Get("size")
+	sizeNumber := 24
+	if sizeString != "" {
+		sizeNumber, err = strconv.Atoi(sizeString)
+		if err != nil {
+			return 0, 0, fmt.Errorf("size number query is not number: %w", err)
+		}
+		if sizeNumber < 1 || sizeNumber > maxPageSize {
+			return 0, 0, fmt.Errorf("size number query is not between 1 and %d: %d", maxPageSize, sizeNumber)
+		}
+	}
+
+	return pageNumber, sizeNumber, nil
+}
+
+// uniqueQueryValues drops empty and repeated values of a query parameter
+func uniqueQueryValues(values []string) []string {
+	uniqueValues := []string{}
+	valuesSet := make(map[string]bool)
+	for _, value := range values {
+		if value == "" || valuesSet[value] {
+			continue
+		}
+		valuesSet[value] = true
+		uniqueValues = append(uniqueValues, value)
+	}
+
+	return uniqueValues
+}
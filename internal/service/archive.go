@@ -2,33 +2,118 @@ package service
 
 import (
 	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
 
 	"github.com/imouto1994/yume/internal/model"
 )
 
 type ServiceArchive interface {
-	GetFilesCount(string) (int, error)
+	ProbeBook(context.Context, *model.Book, bool, *string) (*model.BookProbe, error)
+	ProbeBookPreviews(*model.Book) ([]*model.ArchiveEntry, error)
 	StreamFileByIndex(io.Writer, string, int) (string, error)
 }
 
 type serviceArchive struct {
+	serviceImage ServiceImage
 }
 
-func NewServiceArchive() ServiceArchive {
-	return &serviceArchive{}
+func NewServiceArchive(sImage ServiceImage) ServiceArchive {
+	return &serviceArchive{
+		serviceImage: sImage,
+	}
 }
 
-func (s *serviceArchive) GetFilesCount(archivePath string) (int, error) {
-	reader, err := zip.OpenReader(archivePath)
+// ProbeBook lists the pages of a book in reading order, opening the book archive once.
+// The fingerprint identifies the content of the book archive from the names, sizes and checksums of its files,
+// which stay the same when the archive is renamed or moved.
+// Dimensions of pages are read in the same pass when asked for, unless the fingerprint is the known one of the book
+func (s *serviceArchive) ProbeBook(ctx context.Context, book *model.Book, withDimensions bool, knownFingerprint *string) (*model.BookProbe, error) {
+	reader, err := zip.OpenReader(book.URL)
 	if err != nil {
-		return 0, fmt.Errorf("sArchive - failed to open archive: %w", err)
+		return nil, fmt.Errorf("sArchive - failed to open book archive: %w", err)
 	}
 	defer reader.Close()
 
-	return len(reader.File), nil
+	files := getSortedFiles(reader.File)
+	hash := sha256.New()
+	for _, file := range files {
+		fmt.Fprintf(hash, "%s\x00%d\x00%08x\n", file.Name, file.UncompressedSize64, file.CRC32)
+	}
+
+	probe := &model.BookProbe{
+		Fingerprint: hex.EncodeToString(hash.Sum(nil)),
+		Pages:       getArchiveEntries(reader.File, files),
+	}
+	if !withDimensions || (knownFingerprint != nil && *knownFingerprint == probe.Fingerprint) {
+		return probe, nil
+	}
+
+	for _, page := range probe.Pages {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("sArchive - book probe was stopped: %w", err)
+		}
+
+		fileReader, err := reader.File[page.Index].Open()
+		if err != nil {
+			return nil, fmt.Errorf("sArchive - failed to open page in book archive: %w", err)
+		}
+		page.Width, page.Height, err = s.serviceImage.GetDimensions(fileReader)
+		fileReader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("sArchive - failed to use service Image to get dimensions of book page: %w", err)
+		}
+	}
+	probe.HasDimensions = true
+
+	return probe, nil
+}
+
+// ProbeBookPreviews lists the previews of a book in reading order, books without previews archive have none
+func (s *serviceArchive) ProbeBookPreviews(book *model.Book) ([]*model.ArchiveEntry, error) {
+	if book.PreviewURL == nil {
+		return []*model.ArchiveEntry{}, nil
+	}
+
+	reader, err := zip.OpenReader(*book.PreviewURL)
+	if err != nil {
+		return nil, fmt.Errorf("sArchive - failed to open book previews archive: %w", err)
+	}
+	defer reader.Close()
+
+	return getArchiveEntries(reader.File, getSortedFiles(reader.File)), nil
+}
+
+func getSortedFiles(files []*zip.File) []*zip.File {
+	sortedFiles := append([]*zip.File(nil), files...)
+	sort.Slice(sortedFiles, func(i, j int) bool {
+		return sortedFiles[i].Name < sortedFiles[j].Name
+	})
+
+	return sortedFiles
+}
+
+// getArchiveEntries maps sorted files back to their indices in the archive
+func getArchiveEntries(files []*zip.File, sortedFiles []*zip.File) []*model.ArchiveEntry {
+	indexByFile := make(map[*zip.File]int)
+	for index, file := range files {
+		indexByFile[file] = index
+	}
+
+	entries := make([]*model.ArchiveEntry, len(sortedFiles))
+	for number, file := range sortedFiles {
+		entries[number] = &model.ArchiveEntry{
+			Index: indexByFile[file],
+			Name:  file.Name,
+		}
+	}
+
+	return entries
 }
 
 func (s *serviceArchive) StreamFileByIndex(writer io.Writer, archivePath string, index int) (string, error) {
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/imouto1994/yume/internal/repository"
+)
+
+// ServiceAuthor manages the authors and circles parsed from title names
+type ServiceAuthor interface {
+	GetAuthors(context.Context, sqlite.DBOps) ([]*model.Author, error)
+	GetAuthorByID(context.Context, sqlite.DBOps, string) (*model.Author, error)
+	GetOrCreateAuthors(context.Context, sqlite.DBOps, []string) ([]*model.Author, error)
+	GetOrCreateCircles(context.Context, sqlite.DBOps, []string) ([]*model.Circle, error)
+}
+
+type serviceAuthor struct {
+	repositoryAuthor repository.RepositoryAuthor
+	repositoryCircle repository.RepositoryCircle
+}
+
+func NewServiceAuthor(rAuthor repository.RepositoryAuthor, rCircle repository.RepositoryCircle) ServiceAuthor {
+	return &serviceAuthor{
+		repositoryAuthor: rAuthor,
+		repositoryCircle: rCircle,
+	}
+}
+
+func (s *serviceAuthor) GetAuthors(ctx context.Context, dbOps sqlite.DBOps) ([]*model.Author, error) {
+	authors, err := s.repositoryAuthor.FindAll(ctx, dbOps)
+	if err != nil {
+		return nil, fmt.Errorf("sAuthor - failed to find all authors in DB: %w", err)
+	}
+
+	return authors, nil
+}
+
+func (s *serviceAuthor) GetAuthorByID(ctx context.Context, dbOps sqlite.DBOps, authorID string) (*model.Author, error) {
+	author, err := s.repositoryAuthor.FindByID(ctx, dbOps, authorID)
+	if err != nil {
+		return nil, fmt.Errorf("sAuthor - failed to find author by ID in DB: %w", err)
+	}
+
+	return author, nil
+}
+
+// GetOrCreateAuthors returns the authors with given names, creating the ones which do not exist yet
+func (s *serviceAuthor) GetOrCreateAuthors(ctx context.Context, dbOps sqlite.DBOps, names []string) ([]*model.Author, error) {
+	for _, name := range names {
+		err := s.repositoryAuthor.InsertIfNotExists(ctx, dbOps, name)
+		if err != nil {
+			return nil, fmt.Errorf("sAuthor - failed to create author in DB: %w", err)
+		}
+	}
+
+	authors, err := s.repositoryAuthor.FindAllByNames(ctx, dbOps, names)
+	if err != nil {
+		return nil, fmt.Errorf("sAuthor - failed to find authors with given names in DB: %w", err)
+	}
+
+	return authors, nil
+}
+
+// GetOrCreateCircles returns the circles with given names, creating the ones which do not exist yet
+func (s *serviceAuthor) GetOrCreateCircles(ctx context.Context, dbOps sqlite.DBOps, names []string) ([]*model.Circle, error) {
+	for _, name := range names {
+		err := s.repositoryCircle.InsertIfNotExists(ctx, dbOps, name)
+		if err != nil {
+			return nil, fmt.Errorf("sAuthor - failed to create circle in DB: %w", err)
+		}
+	}
+
+	circles, err := s.repositoryCircle.FindAllByNames(ctx, dbOps, names)
+	if err != nil {
+		return nil, fmt.Errorf("sAuthor - failed to find circles with given names in DB: %w", err)
+	}
+
+	return circles, nil
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imouto1994/yume/internal/infra/config"
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"go.uber.org/zap"
+)
+
+const (
+	backupPrefix     = "yume-"
+	backupExtension  = ".db"
+	backupTimeFormat = "20060102T150405.000Z"
+)
+
+type ServiceBackup interface {
+	StartSchedule(sqlite.DB) error
+	CreateBackup(context.Context, sqlite.DB) (*model.Backup, error)
+	GetBackups() ([]*model.Backup, error)
+	RestoreBackup(context.Context, string) error
+}
+
+type serviceBackup struct {
+	backupConfig   config.Backup
+	databaseConfig config.Database
+
+	mutex sync.Mutex
+}
+
+func NewServiceBackup(backupConfig config.Backup, databaseConfig config.Database) ServiceBackup {
+	return &serviceBackup{
+		backupConfig:   backupConfig,
+		databaseConfig: databaseConfig,
+	}
+}
+
+// StartSchedule takes backups on the schedule from config until the process exits
+func (s *serviceBackup) StartSchedule(db sqlite.DB) error {
+	if s.backupConfig.Schedule == "" {
+		return nil
+	}
+
+	schedule, err := parseScanSchedule(s.backupConfig.Schedule)
+	if err != nil {
+		return fmt.Errorf("sBackup - failed to parse backup schedule: %w", err)
+	}
+
+	var scheduleNext func()
+	scheduleNext = func() {
+		next := schedule.Next(time.Now())
+		time.AfterFunc(time.Until(next), func() {
+			backup, err := s.CreateBackup(context.Background(), db)
+			if err != nil {
+				zap.L().Error("sBackup - failed to take scheduled backup", zap.Error(err))
+			} else {
+				zap.L().Info("sBackup - took scheduled backup", zap.String("name", backup.Name))
+			}
+			scheduleNext()
+		})
+	}
+	scheduleNext()
+
+	return nil
+}
+
+// CreateBackup takes a snapshot of the database while it is in use,
+// then deletes the oldest snapshots beyond the number to keep
+func (s *serviceBackup) CreateBackup(ctx context.Context, db sqlite.DB) (*model.Backup, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := os.MkdirAll(s.backupConfig.Directory, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("sBackup - failed to create backup directory: %w", err)
+	}
+
+	name := backupPrefix + time.Now().UTC().Format(backupTimeFormat) + backupExtension
+	err = sqlite.Backup(ctx, db, filepath.Join(s.backupConfig.Directory, name))
+	if err != nil {
+		return nil, fmt.Errorf("sBackup - failed to back up database: %w", err)
+	}
+
+	err = s.rotateBackups()
+	if err != nil {
+		return nil, err
+	}
+
+	backups, err := s.GetBackups()
+	if err != nil {
+		return nil, err
+	}
+	for _, backup := range backups {
+		if backup.Name == name {
+			return backup, nil
+		}
+	}
+
+	return nil, fmt.Errorf("sBackup - backup %s is missing after it was taken", name)
+}
+
+// GetBackups lists the snapshots in the backup directory from the newest to the oldest
+func (s *serviceBackup) GetBackups() ([]*model.Backup, error) {
+	entries, err := os.ReadDir(s.backupConfig.Directory)
+	if os.IsNotExist(err) {
+		return []*model.Backup{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("sBackup - failed to read backup directory: %w", err)
+	}
+
+	backups := []*model.Backup{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupExtension) {
+			continue
+		}
+		createdAt, err := time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, backupPrefix), backupExtension))
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("sBackup - failed to get info of backup file: %w", err)
+		}
+
+		backups = append(backups, &model.Backup{
+			Name:      name,
+			Size:      info.Size(),
+			CreatedAt: createdAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	// Names start with the time of the backup, hence sorting by name sorts by time
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Name > backups[j].Name
+	})
+
+	return backups, nil
+}
+
+func (s *serviceBackup) rotateBackups() error {
+	backups, err := s.GetBackups()
+	if err != nil {
+		return err
+	}
+
+	for i := s.backupConfig.Keep; i < len(backups); i++ {
+		err = os.Remove(filepath.Join(s.backupConfig.Directory, backups[i].Name))
+		if err != nil {
+			return fmt.Errorf("sBackup - failed to delete old backup: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RestoreBackup replaces the database with a snapshot, which is either a path or the name of a snapshot
+// in the backup directory. The server must not be running, the replaced database is kept next to it
+// with the suffix ".pre-restore" along with its journal, and moved back when the snapshot cannot be put in place
+func (s *serviceBackup) RestoreBackup(ctx context.Context, snapshot string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	snapshotPath := snapshot
+	if _, err := os.Stat(snapshotPath); os.IsNotExist(err) && filepath.Base(snapshot) == snapshot {
+		snapshotPath = filepath.Join(s.backupConfig.Directory, snapshot)
+	}
+
+	err := sqlite.CheckIntegrity(ctx, snapshotPath)
+	if err != nil {
+		return fmt.Errorf("sBackup - snapshot cannot be restored: %w", err)
+	}
+
+	err = sqlite.CreateDirectory(s.databaseConfig)
+	if err != nil {
+		return fmt.Errorf("sBackup - failed to prepare database directory: %w", err)
+	}
+
+	// Copy the snapshot next to the database first, so that the database is only replaced by a complete copy
+	databasePath := s.databaseConfig.Path
+	tempPath := databasePath + ".tmp"
+	err = copyFile(snapshotPath, tempPath)
+	if err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("sBackup - failed to copy snapshot: %w", err)
+	}
+
+	// SQLite finds the journal of a database by appending suffixes to its path, hence the journal is moved
+	// along with the replaced database whichever journal mode left it, so that it is never replayed onto the snapshot
+	asidePath := databasePath + ".pre-restore"
+	movedSuffixes := []string{}
+	for _, suffix := range []string{"", "-journal", "-wal", "-shm"} {
+		os.Remove(asidePath + suffix)
+		err = os.Rename(databasePath+suffix, asidePath+suffix)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			os.Remove(tempPath)
+			restoreAsideFiles(databasePath, asidePath, movedSuffixes)
+			return fmt.Errorf("sBackup - failed to move replaced database aside: %w", err)
+		}
+		movedSuffixes = append(movedSuffixes, suffix)
+	}
+
+	err = os.Rename(tempPath, databasePath)
+	if err != nil {
+		os.Remove(tempPath)
+		restoreAsideFiles(databasePath, asidePath, movedSuffixes)
+		return fmt.Errorf("sBackup - failed to move snapshot in place of database: %w", err)
+	}
+
+	return nil
+}
+
+// restoreAsideFiles moves the files of the replaced database back in place after a failed restore,
+// files which cannot be moved back are left with the suffix ".pre-restore"
+func restoreAsideFiles(databasePath string, asidePath string, suffixes []string) {
+	for _, suffix := range suffixes {
+		err := os.Rename(asidePath+suffix, databasePath+suffix)
+		if err != nil {
+			zap.L().Error("sBackup - failed to move replaced database back in place", zap.String("path", asidePath+suffix), zap.Error(err))
+		}
+	}
+}
+
+func copyFile(srcPath string, destPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dest, err := os.Create(destPath)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dest, src)
+	if err != nil {
+		dest.Close()
+		return err
+	}
+	err = dest.Sync()
+	if err != nil {
+		dest.Close()
+		return err
+	}
+
+	return dest.Close()
+}
@@ -1,57 +1,68 @@
 package service
 
 import (
-	"archive/zip"
 	"context"
 	"fmt"
 	"io"
-	"sort"
+	"sync"
+	"time"
 
 	"github.com/imouto1994/yume/internal/infra/sqlite"
 	"github.com/imouto1994/yume/internal/model"
 	"github.com/imouto1994/yume/internal/repository"
+	"go.uber.org/zap"
 )
 
 type ServiceBook interface {
 	CreateBook(context.Context, sqlite.DBOps, *model.Book) error
 	GetBooksByTitleID(context.Context, sqlite.DBOps, string) ([]*model.Book, error)
+	GetBooksByLibraryID(context.Context, sqlite.DBOps, string) ([]*model.Book, error)
 	GetBookByID(context.Context, sqlite.DBOps, string) (*model.Book, error)
 	GetBookPages(context.Context, sqlite.DBOps, string) ([]*model.Page, error)
 	GetBookPreviews(context.Context, sqlite.DBOps, string) ([]*model.Preview, error)
 	StreamBookPageByID(context.Context, sqlite.DBOps, io.Writer, string, int) (string, error)
 	StreamBookPreviewByID(context.Context, sqlite.DBOps, io.Writer, string, int) (string, error)
 	ScanBook(context.Context, sqlite.DBOps, *model.Book) error
+	SaveBookSidecar(context.Context, sqlite.DBOps, string) error
+	UpdateBookLocation(context.Context, sqlite.DBOps, string, string, string) error
 	UpdateBookModifiedTime(context.Context, sqlite.DBOps, string, string) error
 	UpdateBookPreviewInfo(context.Context, sqlite.DBOps, string, *string, *string) error
 	UpdateBookPageCount(context.Context, sqlite.DBOps, string, int) error
+	UpdateBookFingerprint(context.Context, sqlite.DBOps, string, *string) error
+	UpdateBookTags(context.Context, sqlite.DBOps, *model.Book) error
+	GetBookTagsByLibraryID(context.Context, sqlite.DBOps, string) (map[string][]string, error)
+	MoveBookToTitle(context.Context, sqlite.DBOps, string, int64) error
 	UpdateBookPageFavorite(context.Context, sqlite.DBOps, string, int, int) error
 	DeleteBookByID(context.Context, sqlite.DBOps, string) error
-	DeleteBooksByLibraryID(context.Context, sqlite.DBOps, string) error
-	DeleteBooksByTitleID(context.Context, sqlite.DBOps, string) error
-	DeleteBookPages(context.Context, sqlite.DBOps, string) error
-	DeleteBookPreviews(context.Context, sqlite.DBOps, string) error
 }
 
 type serviceBook struct {
 	repositoryBook    repository.RepositoryBook
 	repositoryPage    repository.RepositoryPage
 	repositoryPreview repository.RepositoryPreview
+	repositoryBookTag repository.RepositoryBookTag
 	serviceArchive    ServiceArchive
 	serviceImage      ServiceImage
-}
+	serviceSidecar    ServiceSidecar
+	serviceTag        ServiceTag
 
-type indexedFile struct {
-	Index int
-	File  *zip.File
+	// Sidecar writes of each book are serialised, so that the last write holds the latest favorites
+	sidecarMutex         sync.Mutex
+	sidecarMutexByBookID map[string]*sync.Mutex
 }
 
-func NewServiceBook(rBook repository.RepositoryBook, rPage repository.RepositoryPage, rPreview repository.RepositoryPreview, sArchive ServiceArchive, sImage ServiceImage) ServiceBook {
+func NewServiceBook(rBook repository.RepositoryBook, rPage repository.RepositoryPage, rPreview repository.RepositoryPreview, rBookTag repository.RepositoryBookTag, sArchive ServiceArchive, sImage ServiceImage, sSidecar ServiceSidecar, sTag ServiceTag) ServiceBook {
 	return &serviceBook{
 		repositoryBook:    rBook,
 		repositoryPage:    rPage,
 		repositoryPreview: rPreview,
+		repositoryBookTag: rBookTag,
 		serviceArchive:    sArchive,
 		serviceImage:      sImage,
+		serviceSidecar:    sSidecar,
+		serviceTag:        sTag,
+
+		sidecarMutexByBookID: make(map[string]*sync.Mutex),
 	}
 }
 
@@ -60,6 +71,10 @@ func (s *serviceBook) CreateBook(ctx context.Context, dbOps sqlite.DBOps, book *
 	if err != nil {
 		return fmt.Errorf("sBook - failed to create book in DB: %w", err)
 	}
+	err = s.insertBookTags(ctx, dbOps, book)
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -69,6 +84,31 @@ func (s *serviceBook) GetBooksByTitleID(ctx context.Context, dbOps sqlite.DBOps,
 	if err != nil {
 		return nil, fmt.Errorf("sBook - failed to find all books by given title ID in DB: %w", err)
 	}
+	err = s.fillBooksTags(ctx, dbOps, books)
+	if err != nil {
+		return nil, err
+	}
+
+	return books, nil
+}
+
+func (s *serviceBook) GetBooksByLibraryID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) ([]*model.Book, error) {
+	books, err := s.repositoryBook.FindAllByLibraryID(ctx, dbOps, libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("sBook - failed to find all books by given library ID in DB: %w", err)
+	}
+
+	// Tags of all books in the library are found at once instead of by book IDs
+	tagsByBookURL, err := s.GetBookTagsByLibraryID(ctx, dbOps, libraryID)
+	if err != nil {
+		return nil, err
+	}
+	for _, book := range books {
+		book.Tags = tagsByBookURL[book.URL]
+		if book.Tags == nil {
+			book.Tags = []string{}
+		}
+	}
 
 	return books, nil
 }
@@ -78,6 +118,10 @@ func (s *serviceBook) GetBookByID(ctx context.Context, dbOps sqlite.DBOps, bookI
 	if err != nil {
 		return nil, fmt.Errorf("sBook - failed to find book by given ID in DB: %w", err)
 	}
+	err = s.fillBooksTags(ctx, dbOps, []*model.Book{book})
+	if err != nil {
+		return nil, err
+	}
 
 	return book, nil
 }
@@ -100,81 +144,235 @@ func (s *serviceBook) GetBookPreviews(ctx context.Context, dbOps sqlite.DBOps, b
 	return previews, nil
 }
 
+// ScanBook replaces the pages and previews of a book with the ones in its archives,
+// favorite pages are kept from the previous scan and restored from the sidecar of the book
 func (s *serviceBook) ScanBook(ctx context.Context, dbOps sqlite.DBOps, book *model.Book) error {
-	pagesReader, err := zip.OpenReader(book.URL)
+	// Probe archives before touching DB, so that writes of concurrent book scans are not held up by reading files.
+	// Probes of scanned folders already hold dimensions unless the fingerprint of the book did not change
+	probe := book.Probe
+	if probe == nil || !probe.HasDimensions {
+		var err error
+		probe, err = s.serviceArchive.ProbeBook(ctx, book, true, nil)
+		if err != nil {
+			return fmt.Errorf("sBook - failed to use service Archive to probe book: %w", err)
+		}
+	}
+	previewEntries, err := s.serviceArchive.ProbeBookPreviews(book)
 	if err != nil {
-		return fmt.Errorf("sBook - failed to open book archive: %w", err)
+		return fmt.Errorf("sBook - failed to use service Archive to probe book previews: %w", err)
 	}
-	defer pagesReader.Close()
 
-	indexedPageFiles := make([]*indexedFile, len(pagesReader.File))
-	for index, pageFile := range pagesReader.File {
-		indexedPageFiles[index] = &indexedFile{
-			Index: index,
-			File:  pageFile,
+	pages := make([]*model.Page, len(probe.Pages))
+	for number, entry := range probe.Pages {
+		pages[number] = &model.Page{
+			Index:     entry.Index,
+			Number:    number,
+			FileName:  entry.Name,
+			Width:     entry.Width,
+			Height:    entry.Height,
+			BookID:    book.ID,
+			TitleID:   book.TitleID,
+			LibraryID: book.LibraryID,
 		}
 	}
-	sort.Slice(indexedPageFiles, func(i, j int) bool {
-		return indexedPageFiles[i].File.Name < indexedPageFiles[j].File.Name
-	})
-
-	for number, indexedPageFile := range indexedPageFiles {
-		fileReader, err := indexedPageFile.File.Open()
-		if err != nil {
-			return fmt.Errorf("sBook - failed to open page in book archive: %w", err)
-		}
-		width, height, err := s.serviceImage.GetDimensions(fileReader)
-		if err != nil {
-			return fmt.Errorf("sBook - failed to use service Image to get dimensions of book page: %w", err)
-		}
-		page := &model.Page{
-			Index:     indexedPageFile.Index,
+	previews := make([]*model.Preview, len(previewEntries))
+	for number, entry := range previewEntries {
+		previews[number] = &model.Preview{
+			Index:     entry.Index,
 			Number:    number,
 			BookID:    book.ID,
 			TitleID:   book.TitleID,
 			LibraryID: book.LibraryID,
-			Width:     width,
-			Height:    height,
 		}
-		err = s.repositoryPage.Insert(ctx, dbOps, page)
+	}
+
+	bookID := fmt.Sprintf("%d", book.ID)
+	favorites, sidecar, err := s.getBookFavorites(ctx, dbOps, book)
+	if err != nil {
+		return err
+	}
+	err = s.repositoryPage.DeleteAllByBookID(ctx, dbOps, bookID)
+	if err != nil {
+		return fmt.Errorf("sBook - failed to delete pages of previous book scan in DB: %w", err)
+	}
+	err = s.repositoryPreview.DeleteAllByBookID(ctx, dbOps, bookID)
+	if err != nil {
+		return fmt.Errorf("sBook - failed to delete previews of previous book scan in DB: %w", err)
+	}
+
+	// Favorites of pages which do not exist anymore are dropped
+	favoritePages := []*model.BookSidecarPage{}
+	for _, page := range pages {
+		if favorites.has(page) {
+			page.Favorite = 1
+			favoritePages = append(favoritePages, &model.BookSidecarPage{File: page.FileName, Number: page.Number})
+		}
+	}
+	err = s.repositoryPage.InsertBulk(ctx, dbOps, pages)
+	if err != nil {
+		return fmt.Errorf("sBook - failed to create pages in DB: %w", err)
+	}
+	err = s.repositoryPreview.InsertBulk(ctx, dbOps, previews)
+	if err != nil {
+		return fmt.Errorf("sBook - failed to create previews in DB: %w", err)
+	}
+	if !equalStrings(favorites.updatedAt, book.FavoritesUpdatedAt) {
+		err = s.repositoryBook.UpdateFavoritesTime(ctx, dbOps, bookID, favorites.updatedAt)
 		if err != nil {
-			return fmt.Errorf("sBook - failed to create page in DB: %w", err)
+			return fmt.Errorf("sBook - failed to update time of book favorites in DB: %w", err)
 		}
+		book.FavoritesUpdatedAt = favorites.updatedAt
 	}
 
-	if book.PreviewURL != nil {
-		previewsReader, err := zip.OpenReader(*book.PreviewURL)
+	newSidecar := &model.BookSidecar{
+		FavoritesUpdatedAt: favorites.updatedAt,
+		FavoritePages:      favoritePages,
+	}
+	if !isBookSidecarCurrent(sidecar, newSidecar) {
+		unlock := s.lockBookSidecar(bookID)
+		err = s.serviceSidecar.WriteBookSidecar(book, newSidecar)
+		unlock()
 		if err != nil {
-			return fmt.Errorf("sBook - failed to open book previews archive file: %w", err)
+			zap.L().Error("sBook - failed to use service Sidecar to write book sidecar", zap.String("url", book.URL), zap.Error(err))
 		}
-		defer previewsReader.Close()
-
-		indexedPreviewFiles := make([]*indexedFile, len(previewsReader.File))
-		for index, previewFile := range previewsReader.File {
-			indexedPreviewFiles[index] = &indexedFile{
-				Index: index,
-				File:  previewFile,
-			}
+	}
+
+	return nil
+}
+
+// bookFavorites are the favorite pages of a book, by file name or by number for pages without file names
+type bookFavorites struct {
+	files     map[string]bool
+	numbers   map[int]bool
+	updatedAt *string
+}
+
+func newBookFavorites(pages []*model.BookSidecarPage, updatedAt *string) *bookFavorites {
+	favorites := &bookFavorites{
+		files:     make(map[string]bool),
+		numbers:   make(map[int]bool),
+		updatedAt: updatedAt,
+	}
+	for _, page := range pages {
+		if page.File != "" {
+			favorites.files[page.File] = true
+		} else {
+			favorites.numbers[page.Number] = true
+		}
+	}
+
+	return favorites
+}
+
+func (f *bookFavorites) has(page *model.Page) bool {
+	return f.files[page.FileName] || f.numbers[page.Number]
+}
+
+// getBookFavorites takes the favorite pages of a book from DB or from its sidecar, whichever was changed last.
+// Sidecars are also taken when DB has no favorites of the book and their times are unknown, as after rebuilding DB.
+// The sidecar is returned as well so that it is only written again when it changes
+func (s *serviceBook) getBookFavorites(ctx context.Context, dbOps sqlite.DBOps, book *model.Book) (*bookFavorites, *model.BookSidecar, error) {
+	pages, err := s.repositoryPage.FindAllByBookID(ctx, dbOps, fmt.Sprintf("%d", book.ID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("sBook - failed to find pages of previous book scan in DB: %w", err)
+	}
+	dbFavoritePages := []*model.BookSidecarPage{}
+	for _, page := range pages {
+		if page.Favorite == 1 {
+			dbFavoritePages = append(dbFavoritePages, &model.BookSidecarPage{File: page.FileName, Number: page.Number})
+		}
+	}
+	dbFavorites := newBookFavorites(dbFavoritePages, book.FavoritesUpdatedAt)
+
+	// Unreadable sidecars do not fail the scan, they are replaced by the favorites in DB
+	sidecar, err := s.serviceSidecar.ReadBookSidecar(book)
+	if err != nil {
+		zap.L().Error("sBook - failed to use service Sidecar to read book sidecar", zap.String("url", book.URL), zap.Error(err))
+		return dbFavorites, nil, nil
+	}
+	if sidecar == nil {
+		return dbFavorites, nil, nil
+	}
+
+	dbTime := getFavoritesTime(book.FavoritesUpdatedAt)
+	sidecarTime := getFavoritesTime(sidecar.FavoritesUpdatedAt)
+	if sidecarTime > dbTime || (sidecarTime == dbTime && len(dbFavoritePages) == 0) {
+		return newBookFavorites(sidecar.FavoritePages, sidecar.FavoritesUpdatedAt), sidecar, nil
+	}
+
+	return dbFavorites, sidecar, nil
+}
+
+// isBookSidecarCurrent tells whether a sidecar read from disk already holds the given state,
+// books without sidecar only get one once they have favorites
+func isBookSidecarCurrent(sidecar *model.BookSidecar, newSidecar *model.BookSidecar) bool {
+	if sidecar == nil {
+		return len(newSidecar.FavoritePages) == 0 && newSidecar.FavoritesUpdatedAt == nil
+	}
+	if sidecar.Version < model.BookSidecarVersion || !equalStrings(sidecar.FavoritesUpdatedAt, newSidecar.FavoritesUpdatedAt) {
+		return false
+	}
+	if len(sidecar.FavoritePages) != len(newSidecar.FavoritePages) {
+		return false
+	}
+	for i, page := range sidecar.FavoritePages {
+		if *page != *newSidecar.FavoritePages[i] {
+			return false
 		}
-		sort.Slice(indexedPreviewFiles, func(i, j int) bool {
-			return indexedPreviewFiles[i].File.Name < indexedPreviewFiles[j].File.Name
-		})
-
-		for number, indexedPreviewFile := range indexedPreviewFiles {
-			preview := &model.Preview{
-				Index:     indexedPreviewFile.Index,
-				Number:    number,
-				BookID:    book.ID,
-				TitleID:   book.TitleID,
-				LibraryID: book.LibraryID,
-			}
-			err = s.repositoryPreview.Insert(ctx, dbOps, preview)
-			if err != nil {
-				return fmt.Errorf("sBook - failed to create preview in DB: %w", err)
-			}
+	}
+
+	return true
+}
+
+func getFavoritesTime(favoritesTime *string) string {
+	if favoritesTime == nil {
+		return ""
+	}
+
+	return *favoritesTime
+}
+
+// lockBookSidecar holds the sidecar of a book until the returned function is called
+func (s *serviceBook) lockBookSidecar(bookID string) func() {
+	s.sidecarMutex.Lock()
+	mutex, ok := s.sidecarMutexByBookID[bookID]
+	if !ok {
+		mutex = &sync.Mutex{}
+		s.sidecarMutexByBookID[bookID] = mutex
+	}
+	s.sidecarMutex.Unlock()
+
+	mutex.Lock()
+	return mutex.Unlock
+}
+
+// SaveBookSidecar writes the current favorite pages of a book in DB to its sidecar,
+// DB is read while holding the sidecar so that concurrent saves end with the latest favorites
+func (s *serviceBook) SaveBookSidecar(ctx context.Context, dbOps sqlite.DBOps, bookID string) error {
+	unlock := s.lockBookSidecar(bookID)
+	defer unlock()
+
+	book, err := s.repositoryBook.FindByID(ctx, dbOps, bookID)
+	if err != nil {
+		return fmt.Errorf("sBook - failed to find book with given ID in DB: %w", err)
+	}
+	pages, err := s.repositoryPage.FindAllByBookID(ctx, dbOps, bookID)
+	if err != nil {
+		return fmt.Errorf("sBook - failed to find pages of book with given ID in DB: %w", err)
+	}
+
+	favoritePages := []*model.BookSidecarPage{}
+	for _, page := range pages {
+		if page.Favorite == 1 {
+			favoritePages = append(favoritePages, &model.BookSidecarPage{File: page.FileName, Number: page.Number})
 		}
 	}
 
+	err = s.serviceSidecar.WriteBookSidecar(book, &model.BookSidecar{FavoritesUpdatedAt: book.FavoritesUpdatedAt, FavoritePages: favoritePages})
+	if err != nil {
+		return fmt.Errorf("sBook - failed to use service Sidecar to write book sidecar: %w", err)
+	}
+
 	return nil
 }
 
@@ -210,6 +408,28 @@ func (s *serviceBook) StreamBookPreviewByID(ctx context.Context, dbOps sqlite.DB
 	return extension, nil
 }
 
+// UpdateBookLocation stores the new name and URL of a renamed or moved book, its sidecar is moved along
+func (s *serviceBook) UpdateBookLocation(ctx context.Context, dbOps sqlite.DBOps, bookID string, name string, url string) error {
+	unlock := s.lockBookSidecar(bookID)
+	defer unlock()
+
+	book, err := s.repositoryBook.FindByID(ctx, dbOps, bookID)
+	if err != nil {
+		return fmt.Errorf("sBook - failed to find book with given ID in DB: %w", err)
+	}
+	err = s.repositoryBook.UpdateLocation(ctx, dbOps, bookID, name, url)
+	if err != nil {
+		return fmt.Errorf("sBook - failed to update book's name and URL with given book ID in DB: %w", err)
+	}
+
+	err = s.serviceSidecar.MoveBookSidecar(book, &model.Book{Name: name, URL: url})
+	if err != nil {
+		return fmt.Errorf("sBook - failed to use service Sidecar to move book sidecar: %w", err)
+	}
+
+	return nil
+}
+
 func (s *serviceBook) UpdateBookModifiedTime(ctx context.Context, dbOps sqlite.DBOps, bookID string, modTime string) error {
 	err := s.repositoryBook.UpdateModifiedTime(ctx, dbOps, bookID, modTime)
 	if err != nil {
@@ -237,79 +457,118 @@ func (s *serviceBook) UpdateBookPageCount(ctx context.Context, dbOps sqlite.DBOp
 	return nil
 }
 
-func (s *serviceBook) UpdateBookPageFavorite(ctx context.Context, dbOps sqlite.DBOps, bookID string, pageNumber int, favorite int) error {
-	err := s.repositoryPage.UpdateFavorite(ctx, dbOps, bookID, pageNumber, favorite)
+func (s *serviceBook) UpdateBookFingerprint(ctx context.Context, dbOps sqlite.DBOps, bookID string, fingerprint *string) error {
+	err := s.repositoryBook.UpdateFingerprint(ctx, dbOps, bookID, fingerprint)
 	if err != nil {
-		return fmt.Errorf("sBook - failed to update favoriate of book page with given book ID and page number in DB: %w", err)
+		return fmt.Errorf("sBook - failed to update book's fingerprint with given book ID in DB: %w", err)
 	}
 
 	return nil
 }
 
-func (s *serviceBook) DeleteBookByID(ctx context.Context, dbOps sqlite.DBOps, bookID string) error {
-	err := s.repositoryBook.DeleteByID(ctx, dbOps, bookID)
+// UpdateBookTags replaces the tags of a book with the ones it currently has
+func (s *serviceBook) UpdateBookTags(ctx context.Context, dbOps sqlite.DBOps, book *model.Book) error {
+	err := s.repositoryBookTag.DeleteAllByBookID(ctx, dbOps, fmt.Sprintf("%d", book.ID))
 	if err != nil {
-		return fmt.Errorf("sBook - failed to delete book with given book ID in DB: %w", err)
+		return fmt.Errorf("sBook - failed to delete book tags with given book ID in DB: %w", err)
 	}
-	err = s.repositoryPage.DeleteAllByBookID(ctx, dbOps, bookID)
+
+	return s.insertBookTags(ctx, dbOps, book)
+}
+
+// GetBookTagsByLibraryID returns the tags of all books in a library by their URLs
+func (s *serviceBook) GetBookTagsByLibraryID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) (map[string][]string, error) {
+	bookTags, err := s.repositoryBookTag.FindAllByLibraryID(ctx, dbOps, libraryID)
 	if err != nil {
-		return fmt.Errorf("sBook - failed to delete book pages with given book ID in DB: %w", err)
+		return nil, fmt.Errorf("sBook - failed to find book tags with given library ID in DB: %w", err)
 	}
-	err = s.repositoryPreview.DeleteAllByBookID(ctx, dbOps, bookID)
+
+	tagsByBookURL := make(map[string][]string)
+	for _, bookTag := range bookTags {
+		tagsByBookURL[bookTag.BookURL] = append(tagsByBookURL[bookTag.BookURL], bookTag.Name)
+	}
+
+	return tagsByBookURL, nil
+}
+
+func (s *serviceBook) insertBookTags(ctx context.Context, dbOps sqlite.DBOps, book *model.Book) error {
+	tags, err := s.serviceTag.GetOrCreateTags(ctx, dbOps, book.Tags)
 	if err != nil {
-		return fmt.Errorf("sBook - failed to delete book previews with given book ID in DB: %w", err)
+		return fmt.Errorf("sBook - failed to use service Tag to get tags of book: %w", err)
+	}
+	for _, tag := range tags {
+		err = s.repositoryBookTag.Insert(ctx, dbOps, book.ID, tag.ID, book.TitleID, book.LibraryID)
+		if err != nil {
+			return fmt.Errorf("sBook - failed to add tag of book in DB: %w", err)
+		}
 	}
 
 	return nil
 }
 
-func (s *serviceBook) DeleteBooksByTitleID(ctx context.Context, dbOps sqlite.DBOps, titleID string) error {
-	err := s.repositoryBook.DeleteAllByTitleID(ctx, dbOps, titleID)
-	if err != nil {
-		return fmt.Errorf("sBook - failed to delete books with given title ID in DB: %w", err)
+func (s *serviceBook) fillBooksTags(ctx context.Context, dbOps sqlite.DBOps, books []*model.Book) error {
+	bookIDs := []int64{}
+	bookByID := make(map[int64]*model.Book)
+	for _, book := range books {
+		book.Tags = []string{}
+		bookIDs = append(bookIDs, book.ID)
+		bookByID[book.ID] = book
 	}
-	err = s.repositoryPage.DeleteAllByTitleID(ctx, dbOps, titleID)
+
+	bookTags, err := s.repositoryBookTag.FindAllByBookIDs(ctx, dbOps, bookIDs)
 	if err != nil {
-		return fmt.Errorf("sBook - failed to delete book pages with given title ID in DB: %w", err)
+		return fmt.Errorf("sBook - failed to find tags of books in DB: %w", err)
 	}
-	err = s.repositoryPreview.DeleteAllByTitleID(ctx, dbOps, titleID)
-	if err != nil {
-		return fmt.Errorf("sBook - failed to delete book previews with given title ID in DB: %w", err)
+	for _, bookTag := range bookTags {
+		book := bookByID[bookTag.BookID]
+		book.Tags = append(book.Tags, bookTag.Name)
 	}
 
 	return nil
 }
 
-func (s *serviceBook) DeleteBooksByLibraryID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) error {
-	err := s.repositoryBook.DeleteAllByLibraryID(ctx, dbOps, libraryID)
+func (s *serviceBook) MoveBookToTitle(ctx context.Context, dbOps sqlite.DBOps, bookID string, titleID int64) error {
+	err := s.repositoryBook.UpdateTitleID(ctx, dbOps, bookID, titleID)
+	if err != nil {
+		return fmt.Errorf("sBook - failed to update book's title with given book ID in DB: %w", err)
+	}
+	err = s.repositoryPage.UpdateTitleIDByBookID(ctx, dbOps, bookID, titleID)
 	if err != nil {
-		return fmt.Errorf("sBook - failed to delete books with given library ID in DB: %w", err)
+		return fmt.Errorf("sBook - failed to update title of book pages with given book ID in DB: %w", err)
 	}
-	err = s.repositoryPage.DeleteAllByLibraryID(ctx, dbOps, libraryID)
+	err = s.repositoryPreview.UpdateTitleIDByBookID(ctx, dbOps, bookID, titleID)
 	if err != nil {
-		return fmt.Errorf("sBook - failed to delete book pages with given library ID in DB: %w", err)
+		return fmt.Errorf("sBook - failed to update title of book previews with given book ID in DB: %w", err)
 	}
-	err = s.repositoryPreview.DeleteAllByLibraryID(ctx, dbOps, libraryID)
+	err = s.repositoryBookTag.UpdateTitleIDByBookID(ctx, dbOps, bookID, titleID)
 	if err != nil {
-		return fmt.Errorf("sBook - failed to delete book previews with given library ID in DB: %w", err)
+		return fmt.Errorf("sBook - failed to update title of book tags with given book ID in DB: %w", err)
 	}
 
 	return nil
 }
 
-func (s *serviceBook) DeleteBookPages(ctx context.Context, dbOps sqlite.DBOps, bookID string) error {
-	err := s.repositoryPage.DeleteAllByBookID(ctx, dbOps, bookID)
+// UpdateBookPageFavorite changes the favorite of a page and records when favorites of its book were changed,
+// which tells whether DB or the book sidecar holds the latest favorites
+func (s *serviceBook) UpdateBookPageFavorite(ctx context.Context, dbOps sqlite.DBOps, bookID string, pageNumber int, favorite int) error {
+	err := s.repositoryPage.UpdateFavorite(ctx, dbOps, bookID, pageNumber, favorite)
 	if err != nil {
-		return fmt.Errorf("sBook - failed to delete book pages with given book ID in DB: %w", err)
+		return fmt.Errorf("sBook - failed to update favoriate of book page with given book ID and page number in DB: %w", err)
+	}
+	favoritesTime := time.Now().UTC().Format(model.FavoritesTimeFormat)
+	err = s.repositoryBook.UpdateFavoritesTime(ctx, dbOps, bookID, &favoritesTime)
+	if err != nil {
+		return fmt.Errorf("sBook - failed to update time of book favorites with given book ID in DB: %w", err)
 	}
 
 	return nil
 }
 
-func (s *serviceBook) DeleteBookPreviews(ctx context.Context, dbOps sqlite.DBOps, bookID string) error {
-	err := s.repositoryPreview.DeleteAllByBookID(ctx, dbOps, bookID)
+// DeleteBookByID deletes a book, its pages, previews and tags are deleted along by cascade
+func (s *serviceBook) DeleteBookByID(ctx context.Context, dbOps sqlite.DBOps, bookID string) error {
+	err := s.repositoryBook.DeleteByID(ctx, dbOps, bookID)
 	if err != nil {
-		return fmt.Errorf("sBook - failed to delete book previews with given book ID in DB: %w", err)
+		return fmt.Errorf("sBook - failed to delete book with given book ID in DB: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/imouto1994/yume/internal/infra/config"
+	"github.com/imouto1994/yume/internal/model"
+)
+
+type ServiceConvention interface {
+	GetConventions() map[string]*config.Convention
+	HasConvention(string) bool
+	ParseTitleName(string, string) (*model.TitleNameInfo, error)
+	ParseBookName(string, string) (*model.BookNameInfo, error)
+}
+
+type serviceConvention struct {
+	conventions        map[string]*config.Convention
+	parserByConvention map[string]*conventionParser
+}
+
+type conventionParser struct {
+	convention    *config.Convention
+	titlePatterns []*regexp.Regexp
+	bookPatterns  []*regexp.Regexp
+	flagByValue   map[string]string
+}
+
+func NewServiceConvention(conventions map[string]*config.Convention) ServiceConvention {
+	parserByConvention := make(map[string]*conventionParser)
+	for name, convention := range conventions {
+		// Patterns are already checked when the config is loaded
+		parser := &conventionParser{
+			convention:  convention,
+			flagByValue: make(map[string]string),
+		}
+		for _, pattern := range convention.TitlePatterns {
+			parser.titlePatterns = append(parser.titlePatterns, regexp.MustCompile(pattern))
+		}
+		for _, pattern := range convention.BookPatterns {
+			parser.bookPatterns = append(parser.bookPatterns, regexp.MustCompile(pattern))
+		}
+		for flag, values := range convention.Flags {
+			for _, value := range values {
+				parser.flagByValue[value] = flag
+			}
+		}
+		parserByConvention[name] = parser
+	}
+
+	return &serviceConvention{
+		conventions:        conventions,
+		parserByConvention: parserByConvention,
+	}
+}
+
+func (s *serviceConvention) GetConventions() map[string]*config.Convention {
+	return s.conventions
+}
+
+func (s *serviceConvention) HasConvention(conventionName string) bool {
+	_, ok := s.parserByConvention[conventionName]
+	return ok
+}
+
+func (s *serviceConvention) ParseTitleName(conventionName string, titleName string) (*model.TitleNameInfo, error) {
+	parser, ok := s.parserByConvention[conventionName]
+	if !ok {
+		return nil, fmt.Errorf("sConvention - %w: no convention with given name", model.ErrNotFound)
+	}
+
+	info := &model.TitleNameInfo{
+		Authors:  []string{},
+		Circles:  []string{},
+		Captures: captureNamedGroups(parser.titlePatterns, titleName),
+	}
+
+	// Check for specified created time
+	if createdAt, ok := info.Captures[model.TitleCaptureCreatedAt]; ok {
+		createdTime, err := time.Parse(parser.convention.DateFormat, createdAt)
+		if err == nil {
+			createdTimeString := createdTime.UTC().Format(time.RFC3339)
+			info.CreatedAt = &createdTimeString
+		}
+	}
+
+	info.Authors = splitNames(info.Captures[model.TitleCaptureAuthor], parser.convention.NameSeparator)
+	info.Circles = splitNames(info.Captures[model.TitleCaptureCircle], parser.convention.NameSeparator)
+
+	return info, nil
+}
+
+func (s *serviceConvention) ParseBookName(conventionName string, bookName string) (*model.BookNameInfo, error) {
+	parser, ok := s.parserByConvention[conventionName]
+	if !ok {
+		return nil, fmt.Errorf("sConvention - %w: no convention with given name", model.ErrNotFound)
+	}
+
+	info := &model.BookNameInfo{
+		Lang:     parser.convention.DefaultLang,
+		Flags:    []string{},
+		Captures: captureNamedGroups(parser.bookPatterns, bookName),
+	}
+
+	if lang, ok := info.Captures[model.BookCaptureLang]; ok {
+		info.Lang = lang
+	}
+
+	// Map flag values to the flags they stand for, other values are kept as they are
+	if flagValues, ok := info.Captures[model.BookCaptureFlags]; ok {
+		flagsSet := make(map[string]bool)
+		for _, flagValue := range strings.Split(flagValues, parser.convention.FlagSeparator) {
+			flagValue = strings.TrimSpace(flagValue)
+			if flagValue == "" {
+				continue
+			}
+			if flag, ok := parser.flagByValue[flagValue]; ok {
+				flagsSet[flag] = true
+			} else {
+				flagsSet[flagValue] = true
+			}
+		}
+		for flag := range flagsSet {
+			info.Flags = append(info.Flags, flag)
+		}
+		sort.Strings(info.Flags)
+	}
+
+	return info, nil
+}
+
+// splitNames splits the captured names of authors or circles, dropping empty and repeated ones
+func splitNames(names string, separator string) []string {
+	result := []string{}
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(names, separator) {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+
+	return result
+}
+
+// captureNamedGroups collects the named captures of all patterns matching the name,
+// earlier patterns take precedence over later ones for the same capture
+func captureNamedGroups(patterns []*regexp.Regexp, name string) map[string]string {
+	captures := make(map[string]string)
+	for _, pattern := range patterns {
+		match := pattern.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		for i, captureName := range pattern.SubexpNames() {
+			if captureName == "" {
+				continue
+			}
+			if _, ok := captures[captureName]; !ok {
+				captures[captureName] = match[i]
+			}
+		}
+	}
+
+	return captures
+}
@@ -0,0 +1,359 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/imouto1994/yume/internal/model"
+)
+
+// diffTitleFields lists the fields of a title in DB which differ from its scanned state
+func diffTitleFields(dbTitle *model.Title, title *model.Title) []*model.FieldChange {
+	changes := []*model.FieldChange{}
+
+	if dbTitle.Name != title.Name {
+		changes = append(changes, &model.FieldChange{Field: model.TitleFieldName, Before: dbTitle.Name, After: title.Name})
+	}
+	if dbTitle.URL != title.URL {
+		changes = append(changes, &model.FieldChange{Field: model.TitleFieldURL, Before: dbTitle.URL, After: title.URL})
+	}
+	if dbTitle.GroupPath != title.GroupPath {
+		changes = append(changes, &model.FieldChange{Field: model.TitleFieldGroupPath, Before: dbTitle.GroupPath, After: title.GroupPath})
+	}
+	// Created time is only kept in sync when it is specified in the title name
+	if title.CreatedAtFromName && dbTitle.CreatedAt != title.CreatedAt {
+		changes = append(changes, &model.FieldChange{Field: model.TitleFieldCreatedAt, Before: dbTitle.CreatedAt, After: title.CreatedAt})
+	}
+	if dbTitle.UpdatedAt != title.UpdatedAt {
+		changes = append(changes, &model.FieldChange{Field: model.TitleFieldUpdatedAt, Before: dbTitle.UpdatedAt, After: title.UpdatedAt})
+	}
+	if dbTitle.CoverWidth != title.CoverWidth || dbTitle.CoverHeight != title.CoverHeight {
+		changes = append(changes, &model.FieldChange{Field: model.TitleFieldCover, Before: coverDimension(dbTitle), After: coverDimension(title)})
+	}
+	if !equalStrings(dbTitle.CoverHash, title.CoverHash) {
+		changes = append(changes, &model.FieldChange{Field: model.TitleFieldCoverHash, Before: dbTitle.CoverHash, After: title.CoverHash})
+	}
+	if dbTitle.Langs != title.Langs {
+		changes = append(changes, &model.FieldChange{Field: model.TitleFieldLangs, Before: dbTitle.Langs, After: title.Langs})
+	}
+	if dbTitle.BookCount != title.BookCount {
+		changes = append(changes, &model.FieldChange{Field: model.TitleFieldBookCount, Before: dbTitle.BookCount, After: title.BookCount})
+	}
+	if !equalStringSets(dbTitle.Tags, title.Tags) {
+		changes = append(changes, &model.FieldChange{Field: model.TitleFieldTags, Before: dbTitle.Tags, After: title.Tags})
+	}
+	if !equalStringSets(dbTitle.Authors, title.Authors) {
+		changes = append(changes, &model.FieldChange{Field: model.TitleFieldAuthors, Before: dbTitle.Authors, After: title.Authors})
+	}
+	if !equalStringSets(dbTitle.Circles, title.Circles) {
+		changes = append(changes, &model.FieldChange{Field: model.TitleFieldCircles, Before: dbTitle.Circles, After: title.Circles})
+	}
+
+	return changes
+}
+
+// diffBookFields lists the fields of a book in DB which differ from its scanned state
+func diffBookFields(dbBook *model.Book, book *model.Book) []*model.FieldChange {
+	changes := []*model.FieldChange{}
+
+	if dbBook.Name != book.Name {
+		changes = append(changes, &model.FieldChange{Field: model.BookFieldName, Before: dbBook.Name, After: book.Name})
+	}
+	if dbBook.URL != book.URL {
+		changes = append(changes, &model.FieldChange{Field: model.BookFieldURL, Before: dbBook.URL, After: book.URL})
+	}
+	if dbBook.UpdatedAt != book.UpdatedAt {
+		changes = append(changes, &model.FieldChange{Field: model.BookFieldUpdatedAt, Before: dbBook.UpdatedAt, After: book.UpdatedAt})
+	}
+	if dbBook.PageCount != book.PageCount {
+		changes = append(changes, &model.FieldChange{Field: model.BookFieldPageCount, Before: dbBook.PageCount, After: book.PageCount})
+	}
+	if !equalStrings(dbBook.PreviewURL, book.PreviewURL) || !equalStrings(dbBook.PreviewUpdatedAt, book.PreviewUpdatedAt) {
+		changes = append(changes, &model.FieldChange{Field: model.BookFieldPreview, Before: previewInfo(dbBook), After: previewInfo(book)})
+	}
+	if !equalStrings(dbBook.Fingerprint, book.Fingerprint) {
+		changes = append(changes, &model.FieldChange{Field: model.BookFieldFingerprint, Before: dbBook.Fingerprint, After: book.Fingerprint})
+	}
+	if !equalStringSets(dbBook.Tags, book.Tags) {
+		changes = append(changes, &model.FieldChange{Field: model.BookFieldTags, Before: dbBook.Tags, After: book.Tags})
+	}
+
+	return changes
+}
+
+// diffTitleBooks matches the books of a title in DB with the scanned ones by name,
+// or by fingerprint for books which were renamed
+func diffTitleBooks(titleDiff *model.TitleDiff, dbBooks []*model.Book, books []*model.Book) {
+	dbBookByBookName := make(map[string]*model.Book)
+	bookByBookName := make(map[string]*model.Book)
+	for _, dbBook := range dbBooks {
+		dbBookByBookName[dbBook.Name] = dbBook
+	}
+	for _, book := range books {
+		bookByBookName[book.Name] = book
+	}
+
+	unmatchedDBBookByFingerprint := make(map[string]*model.Book)
+	for _, dbBook := range dbBooks {
+		if _, ok := bookByBookName[dbBook.Name]; !ok && dbBook.Fingerprint != nil {
+			unmatchedDBBookByFingerprint[*dbBook.Fingerprint] = dbBook
+		}
+	}
+
+	matchedDBBookIDs := make(map[int64]bool)
+	for _, book := range books {
+		dbBook, ok := dbBookByBookName[book.Name]
+		if !ok && book.Fingerprint != nil {
+			dbBook, ok = unmatchedDBBookByFingerprint[*book.Fingerprint]
+			delete(unmatchedDBBookByFingerprint, *book.Fingerprint)
+		}
+		if !ok {
+			titleDiff.AddedBooks = append(titleDiff.AddedBooks, newBookDiff(nil, book))
+			continue
+		}
+		matchedDBBookIDs[dbBook.ID] = true
+
+		bookDiff := newBookDiff(dbBook, book)
+		bookDiff.Changes = diffBookFields(dbBook, book)
+		if len(bookDiff.Changes) > 0 {
+			titleDiff.UpdatedBooks = append(titleDiff.UpdatedBooks, bookDiff)
+		} else {
+			titleDiff.UnchangedBooks = append(titleDiff.UnchangedBooks, bookDiff)
+		}
+	}
+
+	for _, dbBook := range dbBooks {
+		if !matchedDBBookIDs[dbBook.ID] {
+			titleDiff.RemovedBooks = append(titleDiff.RemovedBooks, newBookDiff(dbBook, nil))
+		}
+	}
+}
+
+// matchRenamedTitles pairs scanned titles missing from DB with DB titles missing from the library,
+// based on their cover hashes and the fingerprints of their books
+func matchRenamedTitles(dbTitles []*model.Title, dbBooksByTitleID map[int64][]*model.Book, titles []*model.Title, booksByTitleURL map[string][]*model.Book) map[string]*model.Title {
+	type candidate struct {
+		dbTitle *model.Title
+		title   *model.Title
+		score   int
+	}
+
+	candidates := []*candidate{}
+	for _, title := range titles {
+		fingerprints := make(map[string]bool)
+		for _, book := range booksByTitleURL[title.URL] {
+			if book.Fingerprint != nil {
+				fingerprints[*book.Fingerprint] = true
+			}
+		}
+
+		for _, dbTitle := range dbTitles {
+			dbBooks := dbBooksByTitleID[dbTitle.ID]
+			numSharedBooks := 0
+			for _, dbBook := range dbBooks {
+				if dbBook.Fingerprint != nil && fingerprints[*dbBook.Fingerprint] {
+					numSharedBooks++
+				}
+			}
+
+			// Titles are the same when they share their cover or most of their books
+			coverMatched := dbTitle.CoverHash != nil && equalStrings(dbTitle.CoverHash, title.CoverHash)
+			numBooks := len(dbBooks)
+			if len(booksByTitleURL[title.URL]) > numBooks {
+				numBooks = len(booksByTitleURL[title.URL])
+			}
+			booksMatched := numSharedBooks*2 > numBooks
+			if !coverMatched && !booksMatched {
+				continue
+			}
+
+			score := numSharedBooks
+			if coverMatched {
+				score++
+			}
+			candidates = append(candidates, &candidate{dbTitle: dbTitle, title: title, score: score})
+		}
+	}
+
+	// Best matches are paired first, each title is paired at most once
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		if candidates[i].dbTitle.ID != candidates[j].dbTitle.ID {
+			return candidates[i].dbTitle.ID < candidates[j].dbTitle.ID
+		}
+		return candidates[i].title.URL < candidates[j].title.URL
+	})
+
+	dbTitleByTitleURL := make(map[string]*model.Title)
+	matchedDBTitleIDs := make(map[int64]bool)
+	for _, c := range candidates {
+		if _, ok := dbTitleByTitleURL[c.title.URL]; ok || matchedDBTitleIDs[c.dbTitle.ID] {
+			continue
+		}
+		dbTitleByTitleURL[c.title.URL] = c.dbTitle
+		matchedDBTitleIDs[c.dbTitle.ID] = true
+	}
+
+	return dbTitleByTitleURL
+}
+
+// matchMovedBooks turns books removed from a title and added to another one into moves,
+// based on their fingerprints
+func matchMovedBooks(scanDiff *model.ScanDiff) {
+	type removedBook struct {
+		titleDiff *model.TitleDiff
+		bookDiff  *model.BookDiff
+	}
+
+	removedBookByFingerprint := make(map[string]*removedBook)
+	for _, titleDiffs := range [][]*model.TitleDiff{scanDiff.UpdatedTitles, scanDiff.RemovedTitles} {
+		for _, titleDiff := range titleDiffs {
+			for _, bookDiff := range titleDiff.RemovedBooks {
+				if bookDiff.DBBook.Fingerprint != nil {
+					removedBookByFingerprint[*bookDiff.DBBook.Fingerprint] = &removedBook{titleDiff: titleDiff, bookDiff: bookDiff}
+				}
+			}
+		}
+	}
+	if len(removedBookByFingerprint) == 0 {
+		return
+	}
+
+	for _, titleDiffs := range [][]*model.TitleDiff{scanDiff.AddedTitles, scanDiff.UpdatedTitles} {
+		for _, titleDiff := range titleDiffs {
+			addedBooks := []*model.BookDiff{}
+			for _, bookDiff := range titleDiff.AddedBooks {
+				var removed *removedBook
+				if bookDiff.Book.Fingerprint != nil {
+					removed = removedBookByFingerprint[*bookDiff.Book.Fingerprint]
+				}
+				if removed == nil {
+					addedBooks = append(addedBooks, bookDiff)
+					continue
+				}
+				delete(removedBookByFingerprint, *bookDiff.Book.Fingerprint)
+
+				removedBooks := []*model.BookDiff{}
+				for _, removedBookDiff := range removed.titleDiff.RemovedBooks {
+					if removedBookDiff != removed.bookDiff {
+						removedBooks = append(removedBooks, removedBookDiff)
+					}
+				}
+				removed.titleDiff.RemovedBooks = removedBooks
+
+				dbBook := removed.bookDiff.DBBook
+				movedBookDiff := newBookDiff(dbBook, bookDiff.Book)
+				movedBookDiff.Changes = append([]*model.FieldChange{
+					{Field: model.BookFieldTitle, Before: removed.titleDiff.Name, After: titleDiff.Name},
+				}, diffBookFields(dbBook, bookDiff.Book)...)
+				titleDiff.UpdatedBooks = append(titleDiff.UpdatedBooks, movedBookDiff)
+			}
+			titleDiff.AddedBooks = addedBooks
+		}
+	}
+}
+
+// bookContentChanged tells whether the pages of an updated book have to be scanned again,
+// which is avoided when its fingerprint is unchanged so that page favorites are kept
+func bookContentChanged(bookDiff *model.BookDiff) bool {
+	dbBook := bookDiff.DBBook
+	book := bookDiff.Book
+	fingerprintKnown := dbBook.Fingerprint != nil && book.Fingerprint != nil
+	if fingerprintKnown && *dbBook.Fingerprint != *book.Fingerprint {
+		return true
+	}
+
+	for _, change := range bookDiff.Changes {
+		switch change.Field {
+		case model.BookFieldUpdatedAt, model.BookFieldPageCount:
+			if !fingerprintKnown {
+				return true
+			}
+		case model.BookFieldPreview:
+			if !equalStrings(dbBook.PreviewUpdatedAt, book.PreviewUpdatedAt) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func newTitleDiff(dbTitle *model.Title, title *model.Title) *model.TitleDiff {
+	titleDiff := &model.TitleDiff{
+		Title:   title,
+		DBTitle: dbTitle,
+	}
+	if dbTitle != nil {
+		titleDiff.ID = dbTitle.ID
+		titleDiff.Name = dbTitle.Name
+		titleDiff.URL = dbTitle.URL
+	}
+	if title != nil {
+		titleDiff.Name = title.Name
+		titleDiff.URL = title.URL
+	}
+
+	return titleDiff
+}
+
+func newBookDiff(dbBook *model.Book, book *model.Book) *model.BookDiff {
+	bookDiff := &model.BookDiff{
+		Book:   book,
+		DBBook: dbBook,
+	}
+	if dbBook != nil {
+		bookDiff.ID = dbBook.ID
+		bookDiff.Name = dbBook.Name
+		bookDiff.URL = dbBook.URL
+	}
+	if book != nil {
+		bookDiff.Name = book.Name
+		bookDiff.URL = book.URL
+	}
+
+	return bookDiff
+}
+
+func coverDimension(title *model.Title) map[string]int {
+	return map[string]int{
+		"width":  title.CoverWidth,
+		"height": title.CoverHeight,
+	}
+}
+
+func previewInfo(book *model.Book) map[string]*string {
+	return map[string]*string{
+		"url":        book.PreviewURL,
+		"updated_at": book.PreviewUpdatedAt,
+	}
+}
+
+func equalStrings(a *string, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+// equalStringSets tells whether both lists hold the same strings regardless of their order
+func equalStringSets(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	countByString := make(map[string]int)
+	for _, str := range a {
+		countByString[str]++
+	}
+	for _, str := range b {
+		countByString[str]--
+		if countByString[str] < 0 {
+			return false
+		}
+	}
+
+	return true
+}
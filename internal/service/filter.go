@@ -0,0 +1,343 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/imouto1994/yume/internal/model"
+)
+
+const (
+	maxFilterLength = 1000
+	maxFilterDepth  = 32
+)
+
+const (
+	filterTokenTerm = iota
+	filterTokenAnd
+	filterTokenOr
+	filterTokenNot
+	filterTokenOpen
+	filterTokenClose
+)
+
+type filterToken struct {
+	kind int
+	node model.FilterNode
+}
+
+// parseTitleFilter parses a title filter such as `lang:en uncensored:1 books>3 added>2024-01-01 -tag:ntr`,
+// terms are combined with AND unless joined by OR, negated with a leading - or NOT and grouped with parentheses,
+// an empty filter gives no node
+func parseTitleFilter(filter string) (model.FilterNode, error) {
+	if len(filter) > maxFilterLength {
+		return nil, fmt.Errorf("sTitle - %w: filter is longer than %d characters", model.ErrBadRequest, maxFilterLength)
+	}
+
+	tokens, err := lexTitleFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	parser := &filterParser{tokens: tokens}
+	node, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.position < len(tokens) {
+		return nil, fmt.Errorf("sTitle - %w: unexpected closing parenthesis in filter", model.ErrBadRequest)
+	}
+
+	return node, nil
+}
+
+func lexTitleFilter(filter string) ([]*filterToken, error) {
+	runes := []rune(filter)
+	tokens := []*filterToken{}
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, &filterToken{kind: filterTokenOpen})
+			i++
+		case r == ')':
+			tokens = append(tokens, &filterToken{kind: filterTokenClose})
+			i++
+		case r == '-' && i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) && runes[i+1] != ')':
+			tokens = append(tokens, &filterToken{kind: filterTokenNot})
+			i++
+		case r == '"':
+			value, next, err := readFilterQuoted(runes, i)
+			if err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(value) != "" {
+				tokens = append(tokens, &filterToken{kind: filterTokenTerm, node: &model.FilterTerm{Field: model.FilterFieldText, Operator: model.FilterOperatorEqual, Value: value}})
+			}
+			i = next
+		default:
+			// A run of letters directly followed by an operator is the field of a term
+			j := i
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || runes[j] == '_') {
+				j++
+			}
+			operator, k := readFilterOperator(runes, j)
+			if j > i && operator != "" {
+				value, next, err := readFilterValue(runes, k)
+				if err != nil {
+					return nil, err
+				}
+				node, err := newFilterNode(strings.ToLower(string(runes[i:j])), operator, value)
+				if err != nil {
+					return nil, err
+				}
+				tokens = append(tokens, &filterToken{kind: filterTokenTerm, node: node})
+				i = next
+				continue
+			}
+
+			word, next := readFilterWord(runes, i)
+			switch word {
+			case "AND":
+				tokens = append(tokens, &filterToken{kind: filterTokenAnd})
+			case "OR":
+				tokens = append(tokens, &filterToken{kind: filterTokenOr})
+			case "NOT":
+				tokens = append(tokens, &filterToken{kind: filterTokenNot})
+			default:
+				tokens = append(tokens, &filterToken{kind: filterTokenTerm, node: &model.FilterTerm{Field: model.FilterFieldText, Operator: model.FilterOperatorEqual, Value: word}})
+			}
+			i = next
+		}
+	}
+
+	return tokens, nil
+}
+
+func readFilterOperator(runes []rune, i int) (string, int) {
+	if i >= len(runes) {
+		return "", i
+	}
+
+	switch runes[i] {
+	case ':', '=':
+		return model.FilterOperatorEqual, i + 1
+	case '>':
+		if i+1 < len(runes) && runes[i+1] == '=' {
+			return model.FilterOperatorGreaterEqual, i + 2
+		}
+		return model.FilterOperatorGreater, i + 1
+	case '<':
+		if i+1 < len(runes) && runes[i+1] == '=' {
+			return model.FilterOperatorLessEqual, i + 2
+		}
+		return model.FilterOperatorLess, i + 1
+	}
+
+	return "", i
+}
+
+func readFilterValue(runes []rune, i int) (string, int, error) {
+	var value string
+	next := i
+	if i < len(runes) && runes[i] == '"' {
+		var err error
+		value, next, err = readFilterQuoted(runes, i)
+		if err != nil {
+			return "", 0, err
+		}
+	} else {
+		value, next = readFilterWord(runes, i)
+	}
+
+	if strings.TrimSpace(value) == "" {
+		return "", 0, fmt.Errorf("sTitle - %w: missing value of term in filter", model.ErrBadRequest)
+	}
+
+	return value, next, nil
+}
+
+// readFilterWord reads until the next space or parenthesis
+func readFilterWord(runes []rune, i int) (string, int) {
+	j := i
+	for j < len(runes) && !unicode.IsSpace(runes[j]) && runes[j] != '(' && runes[j] != ')' {
+		j++
+	}
+
+	return string(runes[i:j]), j
+}
+
+// readFilterQuoted reads the text between the quote at given position and the next one
+func readFilterQuoted(runes []rune, i int) (string, int, error) {
+	for j := i + 1; j < len(runes); j++ {
+		if runes[j] == '"' {
+			return string(runes[i+1 : j]), j + 1, nil
+		}
+	}
+
+	return "", 0, fmt.Errorf("sTitle - %w: unterminated quote in filter", model.ErrBadRequest)
+}
+
+// newFilterNode validates a term of a filter, fields which are not known are flags
+// telling whether titles have the tag with the field name, e.g. `uncensored:1`
+func newFilterNode(field string, operator string, value string) (model.FilterNode, error) {
+	term := &model.FilterTerm{
+		Field:    field,
+		Operator: operator,
+		Value:    value,
+	}
+
+	switch field {
+	case model.FilterFieldLibrary, model.FilterFieldAuthorID:
+		_, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("sTitle - %w: %s of filter term is not an ID: %s", model.ErrBadRequest, field, value)
+		}
+	case model.FilterFieldName, model.FilterFieldTag, model.FilterFieldLang, model.FilterFieldAuthor, model.FilterFieldCircle:
+	case model.FilterFieldBooks, model.FilterFieldPages:
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("sTitle - %w: %s of filter term is not a count: %s", model.ErrBadRequest, field, value)
+		}
+		return term, nil
+	case model.FilterFieldAdded, model.FilterFieldUpdated:
+		_, err := time.Parse(model.FilterDateFormat, value)
+		if err != nil {
+			return nil, fmt.Errorf("sTitle - %w: %s of filter term is not a date in format YYYY-MM-DD: %s", model.ErrBadRequest, field, value)
+		}
+		return term, nil
+	default:
+		flag, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("sTitle - %w: unknown field of filter term: %s", model.ErrBadRequest, field)
+		}
+		if operator != model.FilterOperatorEqual {
+			return nil, fmt.Errorf("sTitle - %w: flag %s of filter term can only be compared with :", model.ErrBadRequest, field)
+		}
+		tagTerm := &model.FilterTerm{Field: model.FilterFieldTag, Operator: model.FilterOperatorEqual, Value: field}
+		if !flag {
+			return &model.FilterNot{Node: tagTerm}, nil
+		}
+		return tagTerm, nil
+	}
+
+	if operator != model.FilterOperatorEqual {
+		return nil, fmt.Errorf("sTitle - %w: %s of filter term can only be compared with :", model.ErrBadRequest, field)
+	}
+
+	return term, nil
+}
+
+// filterParser builds the syntax tree of a filter by recursive descent, where OR binds looser than AND
+type filterParser struct {
+	tokens   []*filterToken
+	position int
+	depth    int
+}
+
+func (p *filterParser) peek() *filterToken {
+	if p.position >= len(p.tokens) {
+		return nil
+	}
+
+	return p.tokens[p.position]
+}
+
+func (p *filterParser) parseOr() (model.FilterNode, error) {
+	nodes := []model.FilterNode{}
+	for {
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+
+		token := p.peek()
+		if token == nil || token.kind != filterTokenOr {
+			break
+		}
+		p.position++
+	}
+
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+
+	return &model.FilterOr{Nodes: nodes}, nil
+}
+
+func (p *filterParser) parseAnd() (model.FilterNode, error) {
+	nodes := []model.FilterNode{}
+	for {
+		token := p.peek()
+		if token == nil || token.kind == filterTokenOr || token.kind == filterTokenClose {
+			break
+		}
+		if token.kind == filterTokenAnd {
+			p.position++
+			continue
+		}
+
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("sTitle - %w: missing term in filter", model.ErrBadRequest)
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+
+	return &model.FilterAnd{Nodes: nodes}, nil
+}
+
+func (p *filterParser) parseUnary() (model.FilterNode, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxFilterDepth {
+		return nil, fmt.Errorf("sTitle - %w: filter is nested too deeply", model.ErrBadRequest)
+	}
+
+	token := p.peek()
+	if token == nil {
+		return nil, fmt.Errorf("sTitle - %w: missing term in filter", model.ErrBadRequest)
+	}
+	p.position++
+
+	switch token.kind {
+	case filterTokenNot:
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &model.FilterNot{Node: node}, nil
+	case filterTokenOpen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		token = p.peek()
+		if token == nil || token.kind != filterTokenClose {
+			return nil, fmt.Errorf("sTitle - %w: missing closing parenthesis in filter", model.ErrBadRequest)
+		}
+		p.position++
+		return node, nil
+	case filterTokenTerm:
+		return token.node, nil
+	}
+
+	return nil, fmt.Errorf("sTitle - %w: unexpected token in filter", model.ErrBadRequest)
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/imouto1994/yume/internal/model"
+)
+
+// scanPatterns decides which files and folders in a library are scanned.
+// Patterns without a slash match names of files and folders at any level,
+// other ones match paths relative to the library root
+type scanPatterns struct {
+	includePatterns []string
+	excludePatterns []string
+	includeHidden   bool
+}
+
+func newScanPatterns(library *model.Library) *scanPatterns {
+	return &scanPatterns{
+		includePatterns: library.IncludePatterns,
+		excludePatterns: library.ExcludePatterns,
+		includeHidden:   library.GetIncludeHidden(),
+	}
+}
+
+// skipsFolder tells whether a group folder is left out along with all titles in it
+func (p *scanPatterns) skipsFolder(relativePath string) bool {
+	return p.skipsHidden(relativePath) || matchesAnyScanPattern(p.excludePatterns, relativePath)
+}
+
+// skipsTitle tells whether a title folder is left out,
+// titles need to match one of the include patterns when there is any
+func (p *scanPatterns) skipsTitle(relativePath string) bool {
+	if p.skipsFolder(relativePath) {
+		return true
+	}
+
+	return len(p.includePatterns) > 0 && !matchesAnyScanPattern(p.includePatterns, relativePath)
+}
+
+// skipsFile tells whether a file in a title folder is left out
+func (p *scanPatterns) skipsFile(relativePath string) bool {
+	return p.skipsHidden(relativePath) || matchesAnyScanPattern(p.excludePatterns, relativePath)
+}
+
+func (p *scanPatterns) skipsHidden(relativePath string) bool {
+	return !p.includeHidden && strings.HasPrefix(path.Base(relativePath), ".")
+}
+
+func matchesAnyScanPattern(patterns []string, relativePath string) bool {
+	for _, pattern := range patterns {
+		target := relativePath
+		if !strings.Contains(pattern, "/") {
+			target = path.Base(relativePath)
+		}
+		if matched, _ := path.Match(pattern, target); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateScanPatterns checks the include and exclude patterns of a library
+// and clears the ones left empty
+func validateScanPatterns(library *model.Library) error {
+	for _, patterns := range []*model.Patterns{&library.IncludePatterns, &library.ExcludePatterns} {
+		for _, pattern := range *patterns {
+			if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+				return fmt.Errorf("sLibrary - %w: invalid glob pattern %q", model.ErrBadRequest, pattern)
+			}
+		}
+		if len(*patterns) == 0 {
+			*patterns = nil
+		}
+	}
+
+	return nil
+}
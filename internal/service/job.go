@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"go.uber.org/zap"
+)
+
+type ServiceScanJob interface {
+	StartScan(sqlite.DB, *model.Library) (*model.ScanJob, bool, error)
+	GetScanJob(string) (*model.ScanJob, error)
+	CancelScan(string) (*model.ScanJob, error)
+}
+
+type serviceScanJob struct {
+	serviceLibrary      ServiceLibrary
+	serviceScanProgress ServiceScanProgress
+
+	mutex            sync.Mutex
+	jobByID          map[string]*scanJob
+	jobIDByLibraryID map[int64]string
+}
+
+type scanJob struct {
+	job    model.ScanJob
+	cancel context.CancelFunc
+}
+
+func NewServiceScanJob(sLibrary ServiceLibrary, sScanProgress ServiceScanProgress) ServiceScanJob {
+	return &serviceScanJob{
+		serviceLibrary:      sLibrary,
+		serviceScanProgress: sScanProgress,
+		jobByID:             make(map[string]*scanJob),
+		jobIDByLibraryID:    make(map[int64]string),
+	}
+}
+
+func (s *serviceScanJob) StartScan(db sqlite.DB, library *model.Library) (*model.ScanJob, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Only one scan may run per library, duplicate requests get the running job
+	if jobID, ok := s.jobIDByLibraryID[library.ID]; ok {
+		previousJob := s.jobByID[jobID]
+		if previousJob.job.Status == model.ScanJobStatusRunning {
+			job := previousJob.job
+			return &job, false, nil
+		}
+		delete(s.jobByID, jobID)
+	}
+
+	jobID, err := generateScanJobID()
+	if err != nil {
+		return nil, false, fmt.Errorf("sScanJob - failed to generate scan job ID: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sj := &scanJob{
+		job: model.ScanJob{
+			ID:        jobID,
+			LibraryID: library.ID,
+			Status:    model.ScanJobStatusRunning,
+			StartedAt: time.Now().UTC().Format(time.RFC3339),
+		},
+		cancel: cancel,
+	}
+	s.jobByID[jobID] = sj
+	s.jobIDByLibraryID[library.ID] = jobID
+
+	go s.runScan(ctx, db, library, sj)
+
+	job := sj.job
+	return &job, true, nil
+}
+
+func (s *serviceScanJob) GetScanJob(jobID string) (*model.ScanJob, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sj, ok := s.jobByID[jobID]
+	if !ok {
+		return nil, fmt.Errorf("sScanJob - %w: no scan job with given ID", model.ErrNotFound)
+	}
+
+	job := sj.job
+	return &job, nil
+}
+
+func (s *serviceScanJob) CancelScan(jobID string) (*model.ScanJob, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sj, ok := s.jobByID[jobID]
+	if !ok {
+		return nil, fmt.Errorf("sScanJob - %w: no scan job with given ID", model.ErrNotFound)
+	}
+	sj.cancel()
+
+	job := sj.job
+	return &job, nil
+}
+
+func (s *serviceScanJob) runScan(ctx context.Context, db sqlite.DB, library *model.Library, sj *scanJob) {
+	defer sj.cancel()
+
+	start := time.Now()
+	err := s.scanLibrary(ctx, db, library)
+
+	status := model.ScanJobStatusCompleted
+	event := &model.ScanEvent{
+		LibraryID: library.ID,
+		Stage:     model.ScanStageCompleted,
+	}
+	if errors.Is(err, context.Canceled) {
+		status = model.ScanJobStatusCancelled
+		event.Stage = model.ScanStageCancelled
+		zap.L().Info("sScanJob - cancelled library scan", zap.Int64("libraryID", library.ID))
+	} else if err != nil {
+		status = model.ScanJobStatusFailed
+		event.Stage = model.ScanStageFailed
+		event.Error = err.Error()
+		zap.L().Error("sScanJob - failed to scan and update library", zap.Error(err))
+	} else {
+		zap.L().Info("sScanJob - successfully scanned and updated library", zap.Duration("duration", time.Since(start)))
+	}
+
+	s.mutex.Lock()
+	endedAt := time.Now().UTC().Format(time.RFC3339)
+	sj.job.Status = status
+	sj.job.EndedAt = &endedAt
+	if err != nil {
+		sj.job.Error = err.Error()
+	}
+	s.mutex.Unlock()
+
+	s.serviceScanProgress.Publish(event)
+}
+
+func (s *serviceScanJob) scanLibrary(ctx context.Context, db sqlite.DB, library *model.Library) error {
+	// Transactions of the scan are rolled back automatically once the context is cancelled
+	err := s.serviceLibrary.ScanLibrary(ctx, db, library)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("sScanJob - scan was stopped: %w", ctx.Err())
+		}
+		return fmt.Errorf("sScanJob - failed to use service Library to scan library: %w", err)
+	}
+
+	return nil
+}
+
+func generateScanJobID() (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -2,9 +2,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/infra/worker"
 	"github.com/imouto1994/yume/internal/model"
 	"github.com/imouto1994/yume/internal/repository"
 	"go.uber.org/zap"
@@ -14,28 +18,56 @@ type ServiceLibrary interface {
 	CreateLibrary(context.Context, sqlite.DBOps, *model.Library) error
 	GetLibraries(context.Context, sqlite.DBOps) ([]*model.Library, error)
 	GetLibraryByID(context.Context, sqlite.DBOps, string) (*model.Library, error)
+	GetLibraryDetailByID(context.Context, sqlite.DBOps, string) (*model.LibraryDetail, error)
+	UpdateLibrary(context.Context, sqlite.DBOps, *model.Library) error
+	UpdateLibraryScanRunTimes(context.Context, sqlite.DBOps, *model.Library) error
 	DeleteLibraryByID(context.Context, sqlite.DBOps, string) error
-	ScanLibrary(context.Context, sqlite.DBOps, *model.Library) error
+	ScanLibrary(context.Context, sqlite.DB, *model.Library) error
+	DiffLibrary(context.Context, sqlite.DBOps, *model.Library) (*model.ScanDiff, error)
+	GetLibraryScanErrors(context.Context, sqlite.DBOps, string) ([]*model.ScanError, error)
 }
 
 type serviceLibrary struct {
-	repositoryLibrary repository.RepositoryLibrary
-	serviceScanner    ServiceScanner
-	serviceTitle      ServiceTitle
-	serviceBook       ServiceBook
+	repositoryLibrary     repository.RepositoryLibrary
+	repositoryLibraryScan repository.RepositoryLibraryScan
+	repositoryScanError   repository.RepositoryScanError
+	serviceScanner        ServiceScanner
+	serviceConvention     ServiceConvention
+	serviceScanProgress   ServiceScanProgress
+	serviceTitle          ServiceTitle
+	serviceBook           ServiceBook
+	workerPool            *worker.Pool
 }
 
-func NewServiceLibrary(rLibrary repository.RepositoryLibrary, sScanner ServiceScanner, sTitle ServiceTitle, sBook ServiceBook) ServiceLibrary {
+func NewServiceLibrary(rLibrary repository.RepositoryLibrary, rLibraryScan repository.RepositoryLibraryScan, rScanError repository.RepositoryScanError, sScanner ServiceScanner, sConvention ServiceConvention, sScanProgress ServiceScanProgress, sTitle ServiceTitle, sBook ServiceBook, workerPool *worker.Pool) ServiceLibrary {
 	return &serviceLibrary{
-		repositoryLibrary: rLibrary,
-		serviceScanner:    sScanner,
-		serviceTitle:      sTitle,
-		serviceBook:       sBook,
+		repositoryLibrary:     rLibrary,
+		repositoryLibraryScan: rLibraryScan,
+		repositoryScanError:   rScanError,
+		serviceScanner:        sScanner,
+		serviceConvention:     sConvention,
+		serviceScanProgress:   sScanProgress,
+		serviceTitle:          sTitle,
+		serviceBook:           sBook,
+		workerPool:            workerPool,
 	}
 }
 
 func (s *serviceLibrary) CreateLibrary(ctx context.Context, dbOps sqlite.DBOps, library *model.Library) error {
-	err := s.repositoryLibrary.Insert(ctx, dbOps, library)
+	err := validateScanSchedule(library)
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to validate scan schedule of library: %w", err)
+	}
+	err = s.validateConvention(library)
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to validate convention of library: %w", err)
+	}
+	err = validateScanPatterns(library)
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to validate scan patterns of library: %w", err)
+	}
+
+	err = s.repositoryLibrary.Insert(ctx, dbOps, library)
 	if err != nil {
 		return fmt.Errorf("sLibrary - failed to create library in DB: %w", err)
 	}
@@ -61,250 +93,821 @@ func (s *serviceLibrary) GetLibraryByID(ctx context.Context, dbOps sqlite.DBOps,
 	return library, nil
 }
 
-func (s *serviceLibrary) DeleteLibraryByID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) error {
-	err := s.repositoryLibrary.DeleteByID(ctx, dbOps, libraryID)
+func (s *serviceLibrary) GetLibraryDetailByID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) (*model.LibraryDetail, error) {
+	library, err := s.repositoryLibrary.FindByID(ctx, dbOps, libraryID)
 	if err != nil {
-		return fmt.Errorf("sLibrary - failed to delete library by ID in DB: %w", err)
+		return nil, fmt.Errorf("sLibrary - failed to library by ID in DB: %w", err)
+	}
+
+	// Libraries which have never been scanned have no statistics yet
+	libraryScan, err := s.repositoryLibraryScan.FindByLibraryID(ctx, dbOps, libraryID)
+	if err != nil && !errors.Is(err, model.ErrNotFound) {
+		return nil, fmt.Errorf("sLibrary - failed to get last scan of library in DB: %w", err)
+	}
+
+	totals, err := s.repositoryLibrary.GetTotals(ctx, dbOps, libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("sLibrary - failed to get totals of library in DB: %w", err)
+	}
+
+	return &model.LibraryDetail{
+		Library:  library,
+		LastScan: libraryScan,
+		Totals:   *totals,
+	}, nil
+}
+
+func (s *serviceLibrary) UpdateLibrary(ctx context.Context, dbOps sqlite.DBOps, library *model.Library) error {
+	err := validateScanSchedule(library)
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to validate scan schedule of library: %w", err)
+	}
+	err = s.validateConvention(library)
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to validate convention of library: %w", err)
+	}
+	err = validateScanPatterns(library)
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to validate scan patterns of library: %w", err)
+	}
+
+	err = s.repositoryLibrary.Update(ctx, dbOps, library)
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to update library in DB: %w", err)
+	}
+
+	return nil
+}
+
+// validateConvention checks that the convention of a library is configured
+// and clears it when it is left empty
+func (s *serviceLibrary) validateConvention(library *model.Library) error {
+	if library.Convention != nil && *library.Convention == "" {
+		library.Convention = nil
 	}
-	err = s.serviceTitle.DeleteTitlesByLibraryID(ctx, dbOps, libraryID)
+	if !s.serviceConvention.HasConvention(library.GetConvention()) {
+		return fmt.Errorf("sLibrary - %w: no convention with name %s", model.ErrBadRequest, library.GetConvention())
+	}
+
+	return nil
+}
+
+func (s *serviceLibrary) UpdateLibraryScanRunTimes(ctx context.Context, dbOps sqlite.DBOps, library *model.Library) error {
+	err := s.repositoryLibrary.UpdateScanRunTimes(ctx, dbOps, fmt.Sprintf("%d", library.ID), library.ScanLastRunAt, library.ScanNextRunAt)
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to update scan run times of library in DB: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteLibraryByID deletes a library, the rows referencing it are deleted along by cascade
+// except for the search entries of its titles
+func (s *serviceLibrary) DeleteLibraryByID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) error {
+	err := s.serviceTitle.DeleteTitlesByLibraryID(ctx, dbOps, libraryID)
 	if err != nil {
 		return fmt.Errorf("sLibrary - failed to use service Title to delete titles of deleted library: %w", err)
 	}
-	err = s.serviceBook.DeleteBooksByLibraryID(ctx, dbOps, libraryID)
+	err = s.repositoryLibrary.DeleteByID(ctx, dbOps, libraryID)
 	if err != nil {
-		return fmt.Errorf("sLibrary - failed to use service Book to delete books of deleted library: %w", err)
+		return fmt.Errorf("sLibrary - failed to delete library by ID in DB: %w", err)
 	}
 
 	return nil
 }
 
-func (s *serviceLibrary) ScanLibrary(ctx context.Context, dbOps sqlite.DBOps, library *model.Library) error {
-	scanResult, err := s.serviceScanner.ScanLibraryRoot(library.Root)
+func (s *serviceLibrary) ScanLibrary(ctx context.Context, db sqlite.DB, library *model.Library) error {
+	start := time.Now()
+	libraryScan := &model.LibraryScan{
+		LibraryID: library.ID,
+		StartedAt: start.UTC().Format(time.RFC3339),
+	}
+
+	scanErr := s.scanLibrary(ctx, db, library, libraryScan)
+
+	libraryScan.Status = model.ScanJobStatusCompleted
+	if ctx.Err() != nil {
+		libraryScan.Status = model.ScanJobStatusCancelled
+	} else if scanErr != nil {
+		libraryScan.Status = model.ScanJobStatusFailed
+	}
+	libraryScan.EndedAt = time.Now().UTC().Format(time.RFC3339)
+	libraryScan.DurationMS = time.Since(start).Milliseconds()
+
+	// Statistics are recorded even when the scan was cancelled, hence the context of the scan is not used
+	err := s.repositoryLibraryScan.Upsert(context.Background(), db, libraryScan)
+	if err != nil {
+		if scanErr != nil {
+			zap.L().Error("sLibrary - failed to record statistics of library scan", zap.Error(err))
+			return scanErr
+		}
+		return fmt.Errorf("sLibrary - failed to record statistics of library scan: %w", err)
+	}
+
+	return scanErr
+}
+
+// scanLibrary scans the files of a library and applies the changes to DB,
+// counting the applied changes in the given scan statistics
+func (s *serviceLibrary) scanLibrary(ctx context.Context, db sqlite.DB, library *model.Library, libraryScan *model.LibraryScan) error {
+	s.serviceScanProgress.Publish(&model.ScanEvent{
+		LibraryID: library.ID,
+		Stage:     model.ScanStageStarted,
+	})
+	libraryID := fmt.Sprintf("%d", library.ID)
+
+	scanOptions, err := s.getScanOptions(ctx, db, library, false)
+	if err != nil {
+		return err
+	}
+	scanResult, err := s.serviceScanner.ScanLibraryRoot(ctx, library, scanOptions)
 	if err != nil {
 		return fmt.Errorf("sLibrary - failed to use service Scanner to scan library: %w", err)
 	}
-	zap.L().Info("sLibrary - successfully scanned library files", zap.Int("numTitles", len(scanResult.TitleByTitleName)))
+	zap.L().Info("sLibrary - successfully scanned library files", zap.Int("numTitles", len(scanResult.TitleByTitleURL)))
 
-	// Get all titles in DB
-	dbTitles, err := s.serviceTitle.GetTitlesByLibraryID(ctx, dbOps, fmt.Sprintf("%d", library.ID))
+	// Titles which failed in previous scans are retried even if they are not modified
+	previousScanErrors, err := s.repositoryScanError.FindAllByLibraryID(ctx, db, libraryID)
 	if err != nil {
-		return fmt.Errorf("sLibrary - failed to use service Title get all current titles in scanned library: %w", err)
+		return fmt.Errorf("sLibrary - failed to get scan errors of previous scans: %w", err)
 	}
-	dbTitleByTitleName := make(map[string]*model.Title)
-	for _, dbTitle := range dbTitles {
-		dbTitleByTitleName[dbTitle.Name] = dbTitle
+	scannedTitleURLs := make(map[string]bool)
+	for _, title := range scanResult.TitleByTitleURL {
+		scannedTitleURLs[title.URL] = true
 	}
+	retryTitleURLs := make(map[string]bool)
+	erroredTitleURLs := make(map[string]bool)
+	for _, scanError := range previousScanErrors {
+		if isRetriedScanError(scanError) {
+			retryTitleURLs[scanError.TitleURL] = true
+		}
+		if erroredTitleURLs[scanError.TitleURL] {
+			continue
+		}
+		erroredTitleURLs[scanError.TitleURL] = true
 
-	// Remove titles not existing anymore
-	for _, dbTitle := range dbTitles {
-		if _, ok := scanResult.TitleByTitleName[dbTitle.Name]; !ok {
-			err = s.serviceTitle.DeleteTitleByID(ctx, dbOps, fmt.Sprintf("%d", dbTitle.ID))
+		// Forget errors of titles which are gone from the library
+		if !scannedTitleURLs[scanError.TitleURL] {
+			err = s.repositoryScanError.DeleteAllByTitleURL(ctx, db, libraryID, scanError.TitleURL)
 			if err != nil {
-				return fmt.Errorf("sLibrary - failed to use service Title to delete non-existing titles in scanned library: %w", err)
+				return fmt.Errorf("sLibrary - failed to delete scan errors of non-existing title: %w", err)
 			}
-			zap.L().Info("sLibrary - successfully removed non-existing title", zap.String("name", dbTitle.Name))
+		}
+	}
+
+	scanDiff, err := s.diffLibrary(ctx, db, library, scanResult, retryTitleURLs)
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to compare scanned library with DB: %w", err)
+	}
+
+	// Titles whose folder could not be read are left untouched until the next scan
+	for titleURL, scanErrors := range scanResult.ErrorsByTitleURL {
+		if hasScanErrorStage(scanErrors, model.ScanErrorStageFolder) {
+			err = s.recordScanErrors(ctx, db, libraryID, scanResult.TitleByTitleURL[titleURL], scanErrors)
+			if err != nil {
+				return err
+			}
+			libraryScan.ErrorCount += len(scanErrors)
 		}
 	}
 
 	numBooks := 0
-	for _, books := range scanResult.BooksByTitleName {
+	for _, books := range scanResult.BooksByTitleURL {
 		numBooks += len(books)
 	}
 
-	bookScanChannel := make(chan error, numBooks)
-	for _, title := range scanResult.TitleByTitleName {
-		if dbTitle, ok := dbTitleByTitleName[title.Name]; ok {
-			if dbTitle.UpdatedAt != title.UpdatedAt {
-				// Update title's modified time
-				err := s.serviceTitle.UpdateTitleModifiedTime(ctx, dbOps, fmt.Sprintf("%d", dbTitle.ID), title.UpdatedAt)
-				if err != nil {
-					return fmt.Errorf("sLibrary - failed to use service Title to update title's modified time from scanned library: %w", err)
-				}
-
-				// Update title's cover dimension if necessary
-				if dbTitle.CoverHeight != title.CoverHeight || dbTitle.CoverWidth != title.CoverWidth {
-					err = s.serviceTitle.UpdateTitleCoverDimension(ctx, dbOps, fmt.Sprintf("%d", dbTitle.ID), title.CoverWidth, title.CoverHeight)
-					if err != nil {
-						return fmt.Errorf("sLibrary - failed to use service Title to update title's cover dimension from scanned library: %w", err)
-					}
-				}
-
-				// Update title's supported languages if necessary
-				if dbTitle.Langs != title.Langs {
-					err = s.serviceTitle.UpdateTitleLangs(ctx, dbOps, fmt.Sprintf("%d", dbTitle.ID), title.Langs)
-					if err != nil {
-						return fmt.Errorf("sLibrary - failed to use service Title to update title's supported langs from scanned library: %w", err)
-					}
-				}
-
-				// Update title's book count if necessary
-				if dbTitle.BookCount != title.BookCount {
-					err = s.serviceTitle.UpdateTitleBookCount(ctx, dbOps, fmt.Sprintf("%d", dbTitle.ID), title.BookCount)
-					if err != nil {
-						return fmt.Errorf("sLibrary - failed to use service Title to update title's book count from scanned library: %w", err)
-					}
-				}
-
-				// Update title's flags if necessary
-				if dbTitle.Uncensored != title.Uncensored {
-					err = s.serviceTitle.UpdateTitleUncensored(ctx, dbOps, fmt.Sprintf("%d", dbTitle.ID), title.Uncensored)
-					if err != nil {
-						return fmt.Errorf("sLibrary - failed to use service Title to update title's uncensored flag from scanned library: %w", err)
-					}
-				}
-
-				if dbTitle.Waifu2x != title.Waifu2x {
-					err = s.serviceTitle.UpdateTitleWaifu2x(ctx, dbOps, fmt.Sprintf("%d", dbTitle.ID), title.Waifu2x)
-					if err != nil {
-						return fmt.Errorf("sLibrary - failed to use service Title to update title's waifu2x flag from scanned library: %w", err)
-					}
-				}
-
-				dbBooks, err := s.serviceBook.GetBooksByTitleID(ctx, dbOps, fmt.Sprintf("%d", dbTitle.ID))
-				if err != nil {
-					return fmt.Errorf("sLibrary - failed to use service Book to get all current stored books in updated title from scanned library: %w", err)
-				}
-				books := scanResult.BooksByTitleName[title.Name]
-				dbBookByBookName := make(map[string]*model.Book)
-				bookByBookName := make(map[string]*model.Book)
-				for _, dbBook := range dbBooks {
-					dbBookByBookName[dbBook.Name] = dbBook
-				}
-				for _, book := range books {
-					bookByBookName[book.Name] = book
-				}
-
-				for _, dbBook := range dbBooks {
-					if _, ok := bookByBookName[dbBook.Name]; !ok {
-						// Remove book not existing anymore
-						err = s.serviceBook.DeleteBookByID(ctx, dbOps, fmt.Sprintf("%d", dbBook.ID))
-						if err != nil {
-							return fmt.Errorf("sLibrary - failed to use service Book to delete non-existing books in updated title from scanned library: %w", err)
-						}
-					}
-				}
-
-				for _, book := range books {
-					if dbBook, ok := dbBookByBookName[book.Name]; ok {
-						previewChanged := (dbBook.PreviewUpdatedAt == nil && book.PreviewUpdatedAt != nil) ||
-							(dbBook.PreviewUpdatedAt != nil && book.PreviewUpdatedAt == nil) ||
-							(dbBook.PreviewUpdatedAt != nil && book.PreviewUpdatedAt != nil && *dbBook.PreviewUpdatedAt != *book.PreviewUpdatedAt)
-
-						if dbBook.UpdatedAt != book.UpdatedAt || previewChanged {
-							// Update book's modified time
-							if dbBook.UpdatedAt != book.UpdatedAt {
-								err = s.serviceBook.UpdateBookModifiedTime(ctx, dbOps, fmt.Sprintf("%d", dbBook.ID), book.UpdatedAt)
-								if err != nil {
-									return fmt.Errorf("sLibrary - failed to use service Book to update book's modified time in updated title from scanned library: %w", err)
-								}
-							}
-
-							// Update book's page count
-							if dbBook.PageCount != book.PageCount {
-								err = s.serviceBook.UpdateBookPageCount(ctx, dbOps, fmt.Sprintf("%d", dbBook.ID), book.PageCount)
-								if err != nil {
-									return fmt.Errorf("sLibrary - failed to use service Book to update book's page count in updated title from scanned library: %w", err)
-								}
-							}
-
-							// Update preview
-							if previewChanged {
-								err = s.serviceBook.UpdateBookPreviewInfo(ctx, dbOps, fmt.Sprintf("%d", dbBook.ID), book.PreviewURL, book.PreviewUpdatedAt)
-								if err != nil {
-									return fmt.Errorf("sLibrary - failed to use service Book to update book's preview info in updated title from scanned library: %w", err)
-								}
-							}
-
-							// Delete all pages from updated book in updated title
-							err = s.serviceBook.DeleteBookPages(ctx, dbOps, fmt.Sprintf("%d", dbBook.ID))
-							if err != nil {
-								return fmt.Errorf("sLibrary - failed to use service Book to delete pages of updated book in updated title from scanned library: %w", err)
-							}
-							err = s.serviceBook.DeleteBookPreviews(ctx, dbOps, fmt.Sprintf("%d", dbBook.ID))
-							if err != nil {
-								return fmt.Errorf("sLibrary - failed to use service Book to delete previews of updated book in updated title from scanned library: %w", err)
-							}
-
-							// Rescan all pages from updated book in updated title
-							go func(b *model.Book) {
-								err = s.serviceBook.ScanBook(ctx, dbOps, b)
-								if err != nil {
-									bookScanChannel <- fmt.Errorf("sLibrary - failed to use service Book to scan updated book for updated title from scanned library: %w", err)
-								} else {
-									bookScanChannel <- nil
-								}
-							}(dbBook)
-						} else {
-							bookScanChannel <- nil
-						}
-					} else {
-						book.LibraryID = library.ID
-						book.TitleID = dbTitle.ID
-
-						// Create new book entry in updated title
-						err = s.serviceBook.CreateBook(ctx, dbOps, book)
-						if err != nil {
-							return fmt.Errorf("sLibrary - failed to use service Book to create new book for updated title from scanned library: %w", err)
-						}
-
-						// Scan new book in updated title
-						go func(b *model.Book) {
-							err = s.serviceBook.ScanBook(ctx, dbOps, b)
-							if err != nil {
-								bookScanChannel <- fmt.Errorf("sLibrary - failed to use service Book to scan new book for updated title from scanned library: %w", err)
-							} else {
-								bookScanChannel <- nil
-							}
-						}(book)
-					}
-				}
-				zap.L().Info("sLibrary - successfully updated modified title", zap.String("name", title.Name))
-			} else {
-				books := scanResult.BooksByTitleName[title.Name]
-				for range books {
-					bookScanChannel <- nil
-				}
+	numScannedBooks := int32(0)
+	reportBookScanned := func(name string) {
+		s.serviceScanProgress.Publish(&model.ScanEvent{
+			LibraryID: library.ID,
+			Stage:     model.ScanStageBooks,
+			Name:      name,
+			Done:      int(atomic.AddInt32(&numScannedBooks, 1)),
+			Total:     numBooks,
+		})
+	}
+
+	for _, titleDiff := range scanDiff.UnchangedTitles {
+		for _, bookDiff := range titleDiff.UnchangedBooks {
+			reportBookScanned(bookDiff.Name)
+		}
+		releaseBookProbes(titleDiff, scanResult.BooksByTitleURL[titleDiff.URL])
+	}
+
+	// Each title is committed on its own so that a failing title does not block the others
+	titleDiffs := append(append([]*model.TitleDiff{}, scanDiff.AddedTitles...), scanDiff.UpdatedTitles...)
+	for i, titleDiff := range titleDiffs {
+		s.serviceScanProgress.Publish(&model.ScanEvent{
+			LibraryID: library.ID,
+			Stage:     model.ScanStageTitles,
+			Name:      titleDiff.Name,
+			Done:      i + 1,
+			Total:     len(titleDiffs),
+		})
+
+		scanErrors := scanResult.ErrorsByTitleURL[titleDiff.URL]
+		err = s.scanTitle(ctx, db, library, titleDiff, reportBookScanned)
+		releaseBookProbes(titleDiff, scanResult.BooksByTitleURL[titleDiff.URL])
+		if err != nil {
+			if ctx.Err() != nil {
+				return fmt.Errorf("sLibrary - library scan was stopped: %w", ctx.Err())
 			}
+			zap.L().Error("sLibrary - failed to scan title", zap.String("name", titleDiff.Name), zap.Error(err))
+			scanErrors = append(scanErrors, newTitleScanError(library, titleDiff.Title, err))
+		} else if titleDiff.DBTitle != nil {
+			countTitleDiff(libraryScan, titleDiff)
+			zap.L().Info("sLibrary - successfully updated modified title", zap.String("name", titleDiff.Name))
 		} else {
-			title.LibraryID = library.ID
+			countTitleDiff(libraryScan, titleDiff)
+			zap.L().Info("sLibrary - successfully added new title", zap.String("name", titleDiff.Name))
+		}
 
-			// Create new title entry
-			err = s.serviceTitle.CreateTitle(ctx, dbOps, title)
-			if err != nil {
-				return fmt.Errorf("sLibrary - failed to use service Title to create new title for scanned library: %w", err)
-			}
+		err = s.recordScanErrors(ctx, db, libraryID, titleDiff.Title, scanErrors)
+		if err != nil {
+			return err
+		}
+		libraryScan.ErrorCount += len(scanErrors)
+	}
+
+	// Remove titles not existing anymore, after the books moved out of them were taken over
+	for _, titleDiff := range scanDiff.RemovedTitles {
+		err = s.removeTitle(ctx, db, libraryID, titleDiff.DBTitle)
+		if err != nil {
+			return fmt.Errorf("sLibrary - failed to remove non-existing title in scanned library: %w", err)
+		}
+		countTitleDiff(libraryScan, titleDiff)
+		zap.L().Info("sLibrary - successfully removed non-existing title", zap.String("name", titleDiff.Name))
+	}
+
+	return nil
+}
+
+func (s *serviceLibrary) DiffLibrary(ctx context.Context, dbOps sqlite.DBOps, library *model.Library) (*model.ScanDiff, error) {
+	scanOptions, err := s.getScanOptions(ctx, dbOps, library, true)
+	if err != nil {
+		return nil, err
+	}
+	scanResult, err := s.serviceScanner.ScanLibraryRoot(ctx, library, scanOptions)
+	if err != nil {
+		return nil, fmt.Errorf("sLibrary - failed to use service Scanner to scan library: %w", err)
+	}
+
+	previousScanErrors, err := s.repositoryScanError.FindAllByLibraryID(ctx, dbOps, fmt.Sprintf("%d", library.ID))
+	if err != nil {
+		return nil, fmt.Errorf("sLibrary - failed to get scan errors of previous scans: %w", err)
+	}
+	retryTitleURLs := make(map[string]bool)
+	for _, scanError := range previousScanErrors {
+		if isRetriedScanError(scanError) {
+			retryTitleURLs[scanError.TitleURL] = true
+		}
+	}
+
+	scanDiff, err := s.diffLibrary(ctx, dbOps, library, scanResult, retryTitleURLs)
+	if err != nil {
+		return nil, fmt.Errorf("sLibrary - failed to compare scanned library with DB: %w", err)
+	}
+
+	return scanDiff, nil
+}
+
+func (s *serviceLibrary) getScanOptions(ctx context.Context, dbOps sqlite.DBOps, library *model.Library, dryRun bool) (*model.ScanOptions, error) {
+	dbBooks, err := s.serviceBook.GetBooksByLibraryID(ctx, dbOps, fmt.Sprintf("%d", library.ID))
+	if err != nil {
+		return nil, fmt.Errorf("sLibrary - failed to use service Book to get all current books in scanned library: %w", err)
+	}
+
+	knownBookByURL := make(map[string]*model.Book)
+	for _, dbBook := range dbBooks {
+		knownBookByURL[dbBook.URL] = dbBook
+	}
+
+	return &model.ScanOptions{
+		DryRun:         dryRun,
+		KnownBookByURL: knownBookByURL,
+	}, nil
+}
+
+func (s *serviceLibrary) GetLibraryScanErrors(ctx context.Context, dbOps sqlite.DBOps, libraryID string) ([]*model.ScanError, error) {
+	scanErrors, err := s.repositoryScanError.FindAllByLibraryID(ctx, dbOps, libraryID)
+	if err != nil {
+		return nil, fmt.Errorf("sLibrary - failed to get scan errors of library in DB: %w", err)
+	}
+
+	return scanErrors, nil
+}
+
+// diffLibrary compares the scanned titles and books of a library with the ones stored in DB
+func (s *serviceLibrary) diffLibrary(ctx context.Context, dbOps sqlite.DBOps, library *model.Library, scanResult *model.ScanResult, retryTitleURLs map[string]bool) (*model.ScanDiff, error) {
+	scanDiff := &model.ScanDiff{
+		LibraryID:     library.ID,
+		AddedTitles:   []*model.TitleDiff{},
+		RemovedTitles: []*model.TitleDiff{},
+		UpdatedTitles: []*model.TitleDiff{},
+		Errors:        []*model.ScanError{},
+	}
+
+	// Get all titles in DB
+	dbTitles, err := s.serviceTitle.GetTitlesByLibraryID(ctx, dbOps, fmt.Sprintf("%d", library.ID))
+	if err != nil {
+		return nil, fmt.Errorf("sLibrary - failed to use service Title get all current titles in scanned library: %w", err)
+	}
+	dbTitleByTitleURL := make(map[string]*model.Title)
+	for _, dbTitle := range dbTitles {
+		dbTitleByTitleURL[dbTitle.URL] = dbTitle
+	}
+
+	// Tags of books are parsed from their names which may be read differently after the convention changes
+	dbBookTagsByBookURL, err := s.serviceBook.GetBookTagsByLibraryID(ctx, dbOps, fmt.Sprintf("%d", library.ID))
+	if err != nil {
+		return nil, fmt.Errorf("sLibrary - failed to use service Book to get all current book tags in scanned library: %w", err)
+	}
 
-			books := scanResult.BooksByTitleName[title.Name]
+	newTitles := []*model.Title{}
+	for _, title := range scanResult.TitleByTitleURL {
+		books := scanResult.BooksByTitleURL[title.URL]
+		scanErrors := scanResult.ErrorsByTitleURL[title.URL]
+		scanDiff.Errors = append(scanDiff.Errors, scanErrors...)
+		if hasScanErrorStage(scanErrors, model.ScanErrorStageFolder) {
+			continue
+		}
+
+		dbTitle, ok := dbTitleByTitleURL[title.URL]
+		if !ok {
+			newTitles = append(newTitles, title)
+			continue
+		}
+
+		// Titles scanned before fingerprints were stored are updated once to fill them in,
+		// fields parsed from names change without the folder being modified when the convention changes
+		titleDiff := newTitleDiff(dbTitle, title)
+		titleDiff.Changes = diffTitleFields(dbTitle, title)
+		retry := retryTitleURLs[title.URL] || hasRetriedScanErrors(scanErrors)
+		missingFingerprints := dbTitle.CoverHash == nil && title.CoverHash != nil
+		changedBookTags := false
+		for _, book := range books {
+			if !equalStringSets(dbBookTagsByBookURL[book.URL], book.Tags) {
+				changedBookTags = true
+			}
+		}
+		if dbTitle.UpdatedAt == title.UpdatedAt && len(titleDiff.Changes) == 0 && !changedBookTags && !retry && !missingFingerprints {
 			for _, book := range books {
-				book.LibraryID = library.ID
-				book.TitleID = title.ID
-
-				// Create new book entry for new title
-				err = s.serviceBook.CreateBook(ctx, dbOps, book)
-				if err != nil {
-					return fmt.Errorf("sLibrary - failed to use service Book to create book for new title from scanned library: %w", err)
-				}
-
-				// Scan new book for new title
-				go func(b *model.Book) {
-					err = s.serviceBook.ScanBook(ctx, dbOps, b)
-					if err != nil {
-						bookScanChannel <- fmt.Errorf("sLibrary - failed to use service Book to scan book for new title from scanned library: %w", err)
-					} else {
-						bookScanChannel <- nil
-					}
-				}(book)
+				titleDiff.UnchangedBooks = append(titleDiff.UnchangedBooks, newBookDiff(nil, book))
 			}
-			zap.L().Info("sLibrary - successfully added new title", zap.String("name", title.Name))
+			scanDiff.UnchangedTitles = append(scanDiff.UnchangedTitles, titleDiff)
+			continue
 		}
+
+		dbBooks, err := s.serviceBook.GetBooksByTitleID(ctx, dbOps, fmt.Sprintf("%d", dbTitle.ID))
+		if err != nil {
+			return nil, fmt.Errorf("sLibrary - failed to use service Book to get all current stored books in updated title from scanned library: %w", err)
+		}
+		diffTitleBooks(titleDiff, dbBooks, books)
+		scanDiff.UpdatedTitles = append(scanDiff.UpdatedTitles, titleDiff)
 	}
 
-	for i := 0; i < numBooks; i++ {
-		err := <-bookScanChannel
+	// Titles not found by URL may have been renamed or moved, in which case they keep their identity
+	missingDBTitles := []*model.Title{}
+	missingDBBooksByTitleID := make(map[int64][]*model.Book)
+	for _, dbTitle := range dbTitles {
+		if _, ok := scanResult.TitleByTitleURL[dbTitle.URL]; ok {
+			continue
+		}
+		dbBooks, err := s.serviceBook.GetBooksByTitleID(ctx, dbOps, fmt.Sprintf("%d", dbTitle.ID))
+		if err != nil {
+			return nil, fmt.Errorf("sLibrary - failed to use service Book to get all current stored books in missing title from scanned library: %w", err)
+		}
+		missingDBTitles = append(missingDBTitles, dbTitle)
+		missingDBBooksByTitleID[dbTitle.ID] = dbBooks
+	}
+	renamedDBTitleByTitleURL := matchRenamedTitles(missingDBTitles, missingDBBooksByTitleID, newTitles, scanResult.BooksByTitleURL)
+
+	renamedDBTitleIDs := make(map[int64]bool)
+	for _, title := range newTitles {
+		books := scanResult.BooksByTitleURL[title.URL]
+		if dbTitle, ok := renamedDBTitleByTitleURL[title.URL]; ok {
+			renamedDBTitleIDs[dbTitle.ID] = true
+			titleDiff := newTitleDiff(dbTitle, title)
+			titleDiff.Changes = diffTitleFields(dbTitle, title)
+			diffTitleBooks(titleDiff, missingDBBooksByTitleID[dbTitle.ID], books)
+			scanDiff.UpdatedTitles = append(scanDiff.UpdatedTitles, titleDiff)
+			continue
+		}
+
+		titleDiff := newTitleDiff(nil, title)
+		for _, book := range books {
+			titleDiff.AddedBooks = append(titleDiff.AddedBooks, newBookDiff(nil, book))
+		}
+		scanDiff.AddedTitles = append(scanDiff.AddedTitles, titleDiff)
+	}
+
+	for _, dbTitle := range missingDBTitles {
+		if renamedDBTitleIDs[dbTitle.ID] {
+			continue
+		}
+		titleDiff := newTitleDiff(dbTitle, nil)
+		for _, dbBook := range missingDBBooksByTitleID[dbTitle.ID] {
+			titleDiff.RemovedBooks = append(titleDiff.RemovedBooks, newBookDiff(dbBook, nil))
+		}
+		scanDiff.RemovedTitles = append(scanDiff.RemovedTitles, titleDiff)
+	}
+
+	// Books moved between titles keep their identity as well
+	matchMovedBooks(scanDiff)
+
+	return scanDiff, nil
+}
+
+// releaseBookProbes drops the probes of the books of a title once it is done with,
+// so that a library scan does not hold the pages of every probed archive until it ends
+func releaseBookProbes(titleDiff *model.TitleDiff, books []*model.Book) {
+	for _, book := range books {
+		book.Probe = nil
+	}
+	for _, bookDiff := range titleDiff.UpdatedBooks {
+		bookDiff.DBBook.Probe = nil
+	}
+}
+
+// scanTitle creates or updates a scanned title and scans its books in a transaction of its own
+func (s *serviceLibrary) scanTitle(ctx context.Context, db sqlite.DB, library *model.Library, titleDiff *model.TitleDiff, reportBookScanned func(string)) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to begin SQL transaction for scanning title: %w", err)
+	}
+
+	// Titles which books are moved out of are indexed again as well
+	indexedTitleIDs := []int64{}
+	if titleDiff.DBTitle != nil {
+		indexedTitleIDs = append(indexedTitleIDs, titleDiff.DBTitle.ID)
+	}
+	for _, bookDiff := range titleDiff.UpdatedBooks {
+		if titleDiff.DBTitle == nil || bookDiff.DBBook.TitleID != titleDiff.DBTitle.ID {
+			indexedTitleIDs = append(indexedTitleIDs, bookDiff.DBBook.TitleID)
+		}
+	}
+
+	// Stop in-flight book scans and wait for them before returning,
+	// so that no task keeps writing after the transaction is rolled back
+	bookScanGroup, bookScanCtx := s.workerPool.NewGroup(ctx, library.GetScanWorkers())
+	if titleDiff.DBTitle != nil {
+		err = s.updateTitle(bookScanCtx, tx, bookScanGroup, titleDiff, reportBookScanned)
+	} else {
+		err = s.createTitle(bookScanCtx, tx, bookScanGroup, library, titleDiff, reportBookScanned)
+	}
+	if err != nil {
+		bookScanGroup.Stop()
+	} else {
+		err = bookScanGroup.Wait()
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if titleDiff.DBTitle == nil {
+		indexedTitleIDs = append(indexedTitleIDs, titleDiff.Title.ID)
+	}
+	for _, titleID := range indexedTitleIDs {
+		err = s.serviceTitle.IndexTitle(ctx, tx, fmt.Sprintf("%d", titleID))
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("sLibrary - failed to use service Title to index scanned title: %w", err)
+		}
+	}
+
+	// Clear errors of previous scans now that the title went through
+	err = s.repositoryScanError.DeleteAllByTitleURL(ctx, tx, fmt.Sprintf("%d", library.ID), titleDiff.URL)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("sLibrary - failed to clear previous scan errors of title: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to commit SQL transaction for scanning title: %w", err)
+	}
+
+	return nil
+}
+
+func (s *serviceLibrary) updateTitle(ctx context.Context, dbOps sqlite.DBOps, bookScanGroup *worker.Group, titleDiff *model.TitleDiff, reportBookScanned func(string)) error {
+	title := titleDiff.Title
+	titleID := fmt.Sprintf("%d", titleDiff.DBTitle.ID)
+
+	// Update changed title fields, name and group path are stored along with URL
+	for _, change := range titleDiff.Changes {
+		var err error
+		switch change.Field {
+		case model.TitleFieldURL:
+			err = s.serviceTitle.UpdateTitleLocation(ctx, dbOps, titleID, title.Name, title.URL, title.GroupPath)
+		case model.TitleFieldCreatedAt:
+			err = s.serviceTitle.UpdateTitleCreatedTime(ctx, dbOps, titleID, title.CreatedAt)
+		case model.TitleFieldUpdatedAt:
+			err = s.serviceTitle.UpdateTitleModifiedTime(ctx, dbOps, titleID, title.UpdatedAt)
+		case model.TitleFieldCover:
+			err = s.serviceTitle.UpdateTitleCoverDimension(ctx, dbOps, titleID, title.CoverWidth, title.CoverHeight)
+		case model.TitleFieldCoverHash:
+			err = s.serviceTitle.UpdateTitleCoverHash(ctx, dbOps, titleID, title.CoverHash)
+		case model.TitleFieldLangs:
+			err = s.serviceTitle.UpdateTitleLangs(ctx, dbOps, titleID, title.Langs)
+		case model.TitleFieldBookCount:
+			err = s.serviceTitle.UpdateTitleBookCount(ctx, dbOps, titleID, title.BookCount)
+		case model.TitleFieldTags:
+			err = s.serviceTitle.UpdateTitleTags(ctx, dbOps, &model.Title{ID: titleDiff.DBTitle.ID, LibraryID: titleDiff.DBTitle.LibraryID, Tags: title.Tags})
+		case model.TitleFieldAuthors:
+			err = s.serviceTitle.UpdateTitleAuthors(ctx, dbOps, &model.Title{ID: titleDiff.DBTitle.ID, LibraryID: titleDiff.DBTitle.LibraryID, Authors: title.Authors})
+		case model.TitleFieldCircles:
+			err = s.serviceTitle.UpdateTitleCircles(ctx, dbOps, &model.Title{ID: titleDiff.DBTitle.ID, LibraryID: titleDiff.DBTitle.LibraryID, Circles: title.Circles})
+		}
+		if err != nil {
+			return fmt.Errorf("sLibrary - failed to use service Title to update title's %s from scanned library: %w", change.Field, err)
+		}
+	}
+
+	// Remove books not existing anymore
+	for _, bookDiff := range titleDiff.RemovedBooks {
+		err := s.serviceBook.DeleteBookByID(ctx, dbOps, fmt.Sprintf("%d", bookDiff.ID))
+		if err != nil {
+			return fmt.Errorf("sLibrary - failed to use service Book to delete non-existing books in updated title from scanned library: %w", err)
+		}
+	}
+
+	for _, bookDiff := range titleDiff.UpdatedBooks {
+		err := s.updateBook(ctx, dbOps, bookScanGroup, titleDiff.DBTitle.ID, bookDiff, reportBookScanned)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, bookDiff := range titleDiff.AddedBooks {
+		book := bookDiff.Book
+		book.LibraryID = titleDiff.DBTitle.LibraryID
+		book.TitleID = titleDiff.DBTitle.ID
+
+		err := s.createBook(ctx, dbOps, bookScanGroup, book, reportBookScanned)
 		if err != nil {
 			return err
 		}
 	}
 
+	for _, bookDiff := range titleDiff.UnchangedBooks {
+		reportBookScanned(bookDiff.Name)
+	}
+
+	return nil
+}
+
+func (s *serviceLibrary) createTitle(ctx context.Context, dbOps sqlite.DBOps, bookScanGroup *worker.Group, library *model.Library, titleDiff *model.TitleDiff, reportBookScanned func(string)) error {
+	title := titleDiff.Title
+	title.LibraryID = library.ID
+
+	// Create new title entry
+	err := s.serviceTitle.CreateTitle(ctx, dbOps, title)
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to use service Title to create new title for scanned library: %w", err)
+	}
+
+	for _, bookDiff := range titleDiff.AddedBooks {
+		book := bookDiff.Book
+		book.LibraryID = library.ID
+		book.TitleID = title.ID
+
+		err = s.createBook(ctx, dbOps, bookScanGroup, book, reportBookScanned)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Books moved from other titles
+	for _, bookDiff := range titleDiff.UpdatedBooks {
+		err = s.updateBook(ctx, dbOps, bookScanGroup, title.ID, bookDiff, reportBookScanned)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *serviceLibrary) updateBook(ctx context.Context, dbOps sqlite.DBOps, bookScanGroup *worker.Group, titleID int64, bookDiff *model.BookDiff, reportBookScanned func(string)) error {
+	book := bookDiff.Book
+	dbBook := bookDiff.DBBook
+	bookID := fmt.Sprintf("%d", dbBook.ID)
+	contentChanged := bookContentChanged(bookDiff)
+
+	// Update changed book fields, name is stored along with URL
+	for _, change := range bookDiff.Changes {
+		var err error
+		switch change.Field {
+		case model.BookFieldURL:
+			err = s.serviceBook.UpdateBookLocation(ctx, dbOps, bookID, book.Name, book.URL)
+			dbBook.Name = book.Name
+			dbBook.URL = book.URL
+		case model.BookFieldTitle:
+			err = s.serviceBook.MoveBookToTitle(ctx, dbOps, bookID, titleID)
+			dbBook.TitleID = titleID
+		case model.BookFieldUpdatedAt:
+			err = s.serviceBook.UpdateBookModifiedTime(ctx, dbOps, bookID, book.UpdatedAt)
+			dbBook.UpdatedAt = book.UpdatedAt
+		case model.BookFieldPageCount:
+			err = s.serviceBook.UpdateBookPageCount(ctx, dbOps, bookID, book.PageCount)
+			dbBook.PageCount = book.PageCount
+		case model.BookFieldPreview:
+			err = s.serviceBook.UpdateBookPreviewInfo(ctx, dbOps, bookID, book.PreviewURL, book.PreviewUpdatedAt)
+			dbBook.PreviewURL = book.PreviewURL
+			dbBook.PreviewUpdatedAt = book.PreviewUpdatedAt
+		case model.BookFieldFingerprint:
+			err = s.serviceBook.UpdateBookFingerprint(ctx, dbOps, bookID, book.Fingerprint)
+			dbBook.Fingerprint = book.Fingerprint
+		case model.BookFieldTags:
+			dbBook.Tags = book.Tags
+			err = s.serviceBook.UpdateBookTags(ctx, dbOps, dbBook)
+		}
+		if err != nil {
+			return fmt.Errorf("sLibrary - failed to use service Book to update book's %s in updated title from scanned library: %w", change.Field, err)
+		}
+	}
+
+	// Renamed or moved books with the same content keep their pages
+	if !contentChanged {
+		reportBookScanned(book.Name)
+		return nil
+	}
+
+	// Rescan all pages from updated book in updated title, replacing the ones of the previous scan
+	dbBook.Probe = book.Probe
+	s.scheduleBookScan(bookScanGroup, dbOps, dbBook, reportBookScanned)
+
+	return nil
+}
+
+func (s *serviceLibrary) createBook(ctx context.Context, dbOps sqlite.DBOps, bookScanGroup *worker.Group, book *model.Book, reportBookScanned func(string)) error {
+	// Create new book entry
+	err := s.serviceBook.CreateBook(ctx, dbOps, book)
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to use service Book to create new book from scanned library: %w", err)
+	}
+
+	// Scan new book
+	s.scheduleBookScan(bookScanGroup, dbOps, book, reportBookScanned)
+
+	return nil
+}
+
+func (s *serviceLibrary) scheduleBookScan(bookScanGroup *worker.Group, dbOps sqlite.DBOps, book *model.Book, reportBookScanned func(string)) {
+	bookScanGroup.Go(func(ctx context.Context) error {
+		err := s.serviceBook.ScanBook(ctx, dbOps, book)
+		if err != nil {
+			return &titleScanError{
+				stage: model.ScanErrorStageBook,
+				path:  book.URL,
+				err:   fmt.Errorf("sLibrary - failed to use service Book to scan book from scanned library: %w", err),
+			}
+		}
+		reportBookScanned(book.Name)
+
+		return nil
+	})
+}
+
+func (s *serviceLibrary) removeTitle(ctx context.Context, db sqlite.DB, libraryID string, dbTitle *model.Title) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to begin SQL transaction for removing title: %w", err)
+	}
+
+	err = s.serviceTitle.DeleteTitleByID(ctx, tx, fmt.Sprintf("%d", dbTitle.ID))
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("sLibrary - failed to use service Title to delete title: %w", err)
+	}
+
+	err = s.repositoryScanError.DeleteAllByTitleURL(ctx, tx, libraryID, dbTitle.URL)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("sLibrary - failed to delete scan errors of title: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to commit SQL transaction for removing title: %w", err)
+	}
+
+	return nil
+}
+
+// recordScanErrors replaces the stored scan errors of a title with the ones from the current scan
+func (s *serviceLibrary) recordScanErrors(ctx context.Context, db sqlite.DB, libraryID string, title *model.Title, scanErrors []*model.ScanError) error {
+	if len(scanErrors) == 0 {
+		return nil
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to begin SQL transaction for recording scan errors: %w", err)
+	}
+
+	err = s.repositoryScanError.DeleteAllByTitleURL(ctx, tx, libraryID, title.URL)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("sLibrary - failed to clear previous scan errors of title: %w", err)
+	}
+
+	for _, scanError := range scanErrors {
+		err = s.repositoryScanError.Insert(ctx, tx, scanError)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("sLibrary - failed to record scan error of title: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("sLibrary - failed to commit SQL transaction for recording scan errors: %w", err)
+	}
+
 	return nil
 }
+
+// titleScanError keeps track of the stage and path where the scan of a title failed
+type titleScanError struct {
+	stage string
+	path  string
+	err   error
+}
+
+func (e *titleScanError) Error() string {
+	return e.err.Error()
+}
+
+func (e *titleScanError) Unwrap() error {
+	return e.err
+}
+
+func newTitleScanError(library *model.Library, title *model.Title, err error) *model.ScanError {
+	stage := model.ScanErrorStageTitle
+	path := title.URL
+
+	var tErr *titleScanError
+	if errors.As(err, &tErr) {
+		stage = tErr.stage
+		path = tErr.path
+	}
+
+	return newScanError(library, title, path, stage, err)
+}
+
+// countTitleDiff adds the changes of a title applied to DB to the statistics of a library scan,
+// pages of books with changed content count as updated
+func countTitleDiff(libraryScan *model.LibraryScan, titleDiff *model.TitleDiff) {
+	switch {
+	case titleDiff.DBTitle == nil:
+		libraryScan.TitlesAdded++
+	case titleDiff.Title == nil:
+		libraryScan.TitlesRemoved++
+	default:
+		libraryScan.TitlesUpdated++
+	}
+
+	for _, bookDiff := range titleDiff.AddedBooks {
+		libraryScan.BooksAdded++
+		libraryScan.PagesAdded += bookDiff.Book.PageCount
+	}
+	for _, bookDiff := range titleDiff.RemovedBooks {
+		libraryScan.BooksRemoved++
+		libraryScan.PagesRemoved += bookDiff.DBBook.PageCount
+	}
+	for _, bookDiff := range titleDiff.UpdatedBooks {
+		libraryScan.BooksUpdated++
+		if bookContentChanged(bookDiff) {
+			libraryScan.PagesUpdated += bookDiff.Book.PageCount
+		}
+	}
+}
+
+// isRetriedScanError tells whether the title of a scan error is scanned again even if it is not modified,
+// covers are not retried as they would fail the same way until the title folder changes
+func isRetriedScanError(scanError *model.ScanError) bool {
+	return scanError.Stage != model.ScanErrorStageCover
+}
+
+func hasRetriedScanErrors(scanErrors []*model.ScanError) bool {
+	for _, scanError := range scanErrors {
+		if isRetriedScanError(scanError) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasScanErrorStage(scanErrors []*model.ScanError, stage string) bool {
+	for _, scanError := range scanErrors {
+		if scanError.Stage == stage {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/imouto1994/yume/internal/repository"
+)
+
+type ServiceOrphan interface {
+	FindOrphans(context.Context, sqlite.DBOps) ([]*model.OrphanCount, error)
+	RemoveOrphans(context.Context, sqlite.DBOps) ([]*model.OrphanCount, error)
+}
+
+type serviceOrphan struct {
+	repositoryOrphan repository.RepositoryOrphan
+}
+
+func NewServiceOrphan(rOrphan repository.RepositoryOrphan) ServiceOrphan {
+	return &serviceOrphan{
+		repositoryOrphan: rOrphan,
+	}
+}
+
+// FindOrphans counts the rows of each table which reference rows not existing anymore,
+// such rows were left behind by deletes made before foreign keys were enforced
+func (s *serviceOrphan) FindOrphans(ctx context.Context, dbOps sqlite.DBOps) ([]*model.OrphanCount, error) {
+	orphans, err := s.findForeignKeyOrphanRowIDs(ctx, dbOps)
+	if err != nil {
+		return nil, err
+	}
+	searchRowIDs, err := s.findSearchOrphanRowIDs(ctx, dbOps)
+	if err != nil {
+		return nil, err
+	}
+	if len(searchRowIDs) > 0 {
+		orphans["TITLE_SEARCH"] = searchRowIDs
+	}
+
+	return countOrphans(orphans), nil
+}
+
+// RemoveOrphans deletes the orphaned rows of all tables, rows referencing them are deleted along by cascade.
+// Search entries are only looked for once orphaned titles are deleted, so that their entries are removed as well
+func (s *serviceOrphan) RemoveOrphans(ctx context.Context, dbOps sqlite.DBOps) ([]*model.OrphanCount, error) {
+	orphans, err := s.findForeignKeyOrphanRowIDs(ctx, dbOps)
+	if err != nil {
+		return nil, err
+	}
+	for table, rowIDs := range orphans {
+		err = s.deleteOrphanRows(ctx, dbOps, table, rowIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	searchRowIDs, err := s.findSearchOrphanRowIDs(ctx, dbOps)
+	if err != nil {
+		return nil, err
+	}
+	if len(searchRowIDs) > 0 {
+		err = s.deleteOrphanRows(ctx, dbOps, "TITLE_SEARCH", searchRowIDs)
+		if err != nil {
+			return nil, err
+		}
+		orphans["TITLE_SEARCH"] = searchRowIDs
+	}
+
+	return countOrphans(orphans), nil
+}
+
+func (s *serviceOrphan) deleteOrphanRows(ctx context.Context, dbOps sqlite.DBOps, table string, rowIDs []int64) error {
+	for _, rowID := range rowIDs {
+		err := s.repositoryOrphan.DeleteByRowID(ctx, dbOps, table, rowID)
+		if err != nil {
+			return fmt.Errorf("sOrphan - failed to delete orphaned row in DB: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// findForeignKeyOrphanRowIDs groups the row IDs of rows violating foreign keys by their tables,
+// rows with several missing references are only listed once
+func (s *serviceOrphan) findForeignKeyOrphanRowIDs(ctx context.Context, dbOps sqlite.DBOps) (map[string][]int64, error) {
+	violations, err := s.repositoryOrphan.FindForeignKeyViolations(ctx, dbOps)
+	if err != nil {
+		return nil, fmt.Errorf("sOrphan - failed to find rows violating foreign keys in DB: %w", err)
+	}
+
+	orphans := map[string][]int64{}
+	seen := map[string]map[int64]bool{}
+	for _, violation := range violations {
+		if violation.RowID == nil {
+			continue
+		}
+		if seen[violation.Table] == nil {
+			seen[violation.Table] = map[int64]bool{}
+		}
+		if seen[violation.Table][*violation.RowID] {
+			continue
+		}
+		seen[violation.Table][*violation.RowID] = true
+		orphans[violation.Table] = append(orphans[violation.Table], *violation.RowID)
+	}
+
+	return orphans, nil
+}
+
+// findSearchOrphanRowIDs lists the search entries of titles not existing anymore,
+// the search table is virtual so that it has no foreign keys to check
+func (s *serviceOrphan) findSearchOrphanRowIDs(ctx context.Context, dbOps sqlite.DBOps) ([]int64, error) {
+	searchRowIDs, err := s.repositoryOrphan.FindSearchRowIDs(ctx, dbOps)
+	if err != nil {
+		return nil, fmt.Errorf("sOrphan - failed to find search entries without title in DB: %w", err)
+	}
+
+	return searchRowIDs, nil
+}
+
+func countOrphans(orphans map[string][]int64) []*model.OrphanCount {
+	counts := []*model.OrphanCount{}
+	for table, rowIDs := range orphans {
+		counts = append(counts, &model.OrphanCount{
+			Table: table,
+			Count: len(rowIDs),
+		})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		return counts[i].Table < counts[j].Table
+	})
+
+	return counts
+}
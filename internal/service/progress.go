@@ -0,0 +1,84 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/imouto1994/yume/internal/model"
+)
+
+type ServiceScanProgress interface {
+	Publish(*model.ScanEvent)
+	Subscribe(int64) (<-chan *model.ScanEvent, func())
+}
+
+type serviceScanProgress struct {
+	mutex       sync.Mutex
+	subscribers map[int64]map[chan *model.ScanEvent]struct{}
+	lastEvents  map[int64]*model.ScanEvent
+}
+
+const scanEventBufferSize = 64
+
+func NewServiceScanProgress() ServiceScanProgress {
+	return &serviceScanProgress{
+		subscribers: make(map[int64]map[chan *model.ScanEvent]struct{}),
+		lastEvents:  make(map[int64]*model.ScanEvent),
+	}
+}
+
+func (s *serviceScanProgress) Publish(event *model.ScanEvent) {
+	if event.Time == "" {
+		event.Time = time.Now().UTC().Format(time.RFC3339)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// Only scans in progress are replayed, so that new subscribers are not told about a scan which already ended
+	switch event.Stage {
+	case model.ScanStageCompleted, model.ScanStageFailed, model.ScanStageCancelled:
+		delete(s.lastEvents, event.LibraryID)
+	default:
+		s.lastEvents[event.LibraryID] = event
+	}
+	for channel := range s.subscribers[event.LibraryID] {
+		// Slow subscribers miss intermediate events instead of blocking the scan
+		select {
+		case channel <- event:
+		default:
+		}
+	}
+}
+
+func (s *serviceScanProgress) Subscribe(libraryID int64) (<-chan *model.ScanEvent, func()) {
+	channel := make(chan *model.ScanEvent, scanEventBufferSize)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.subscribers[libraryID]; !ok {
+		s.subscribers[libraryID] = make(map[chan *model.ScanEvent]struct{})
+	}
+	s.subscribers[libraryID][channel] = struct{}{}
+
+	// Replay the latest known state so late subscribers can render progress immediately
+	if lastEvent, ok := s.lastEvents[libraryID]; ok {
+		channel <- lastEvent
+	}
+
+	unsubscribe := func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+
+		if _, ok := s.subscribers[libraryID][channel]; ok {
+			delete(s.subscribers[libraryID], channel)
+			close(channel)
+		}
+		if len(s.subscribers[libraryID]) == 0 {
+			delete(s.subscribers, libraryID)
+		}
+	}
+
+	return channel, unsubscribe
+}
@@ -1,202 +1,305 @@
 package service
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/imouto1994/yume/internal/infra/worker"
 	"github.com/imouto1994/yume/internal/model"
 	"go.uber.org/zap"
 )
 
 type ServiceScanner interface {
-	ScanLibraryRoot(string) (*model.ScanResult, error)
+	ScanLibraryRoot(context.Context, *model.Library, *model.ScanOptions) (*model.ScanResult, error)
 }
 
 type serviceScanner struct {
-	serviceArchive ServiceArchive
-	serviceImage   ServiceImage
+	serviceArchive      ServiceArchive
+	serviceImage        ServiceImage
+	serviceConvention   ServiceConvention
+	serviceScanProgress ServiceScanProgress
+	workerPool          *worker.Pool
 }
 
-type titleCoverScanResult struct {
-	Name   string
-	Width  int
-	Height int
-}
-
-type titleBooksScanResult struct {
-	Name  string
-	Books []*model.Book
-}
-
-func NewServiceScanner(sImage ServiceImage, sArchive ServiceArchive) ServiceScanner {
+func NewServiceScanner(sImage ServiceImage, sArchive ServiceArchive, sConvention ServiceConvention, sScanProgress ServiceScanProgress, workerPool *worker.Pool) ServiceScanner {
 	return &serviceScanner{
-		serviceArchive: sArchive,
-		serviceImage:   sImage,
+		serviceArchive:      sArchive,
+		serviceImage:        sImage,
+		serviceConvention:   sConvention,
+		serviceScanProgress: sScanProgress,
+		workerPool:          workerPool,
 	}
 }
 
-func (s *serviceScanner) ScanLibraryRoot(libraryPath string) (*model.ScanResult, error) {
-	files, err := os.ReadDir(libraryPath)
-	if err != nil {
-		return nil, fmt.Errorf("sScan - failed to read library root folder: %w", err)
+func (s *serviceScanner) ScanLibraryRoot(ctx context.Context, library *model.Library, options *model.ScanOptions) (*model.ScanResult, error) {
+	conventionName := library.GetConvention()
+	if !s.serviceConvention.HasConvention(conventionName) {
+		return nil, fmt.Errorf("sScan - %w: library uses unknown convention %s", model.ErrBadRequest, conventionName)
 	}
 
-	// Filter for titles
-	titleFolders := []fs.DirEntry{}
-	for _, file := range files {
-		fileInfo, _ := file.Info()
-		if fileInfo.IsDir() {
-			titleFolders = append(titleFolders, file)
+	// Dry runs are not shown as scans to the subscribers of the library progress
+	publishProgress := func(event *model.ScanEvent) {
+		if !options.DryRun {
+			s.serviceScanProgress.Publish(event)
 		}
 	}
 
-	// Create titles
-	titleByTitleName := make(map[string]*model.Title)
-	for _, titleFolder := range titleFolders {
-		titleName := titleFolder.Name()
-		folderInfo, _ := titleFolder.Info()
-		folderLastModifiedTime := folderInfo.ModTime().UTC().Format(time.RFC3339)
-		titleCreatedTime := folderLastModifiedTime
-
-		// Check for specified created time
-		openedCurlyLastIndex := strings.LastIndex(titleName, "{")
-		closedCurlyLastIndex := strings.LastIndex(titleName, "}")
-		if openedCurlyLastIndex != -1 && closedCurlyLastIndex != -1 && closedCurlyLastIndex > openedCurlyLastIndex {
-			specifiedTimeSubstring := titleName[(openedCurlyLastIndex + 1):closedCurlyLastIndex]
-			specifiedTime, err := time.Parse("2006-01-02", specifiedTimeSubstring)
-			if err == nil {
-				titleCreatedTime = specifiedTime.UTC().Format(time.RFC3339)
-			}
-		}
+	// Filter for titles
+	patterns := newScanPatterns(library)
+	titleFolders, err := s.findTitleFolders(patterns, library.Root, "", library.GetScanDepth())
+	if err != nil {
+		return nil, err
+	}
 
+	// Create titles
+	titles := make([]*model.Title, len(titleFolders))
+	titleByTitleURL := make(map[string]*model.Title)
+	for i, titleFolder := range titleFolders {
+		titleName := titleFolder.info.Name()
+		folderLastModifiedTime := titleFolder.info.ModTime().UTC().Format(time.RFC3339)
 		title := &model.Title{
 			Name:      titleName,
-			URL:       filepath.Join(libraryPath, titleName),
-			CreatedAt: titleCreatedTime,
+			URL:       titleFolder.path,
+			GroupPath: titleFolder.groupPath,
+			CreatedAt: folderLastModifiedTime,
 			UpdatedAt: folderLastModifiedTime,
 		}
-		titleByTitleName[titleName] = title
+
+		// Check for specified created time, authors and circles
+		titleNameInfo, err := s.serviceConvention.ParseTitleName(conventionName, titleName)
+		if err != nil {
+			return nil, fmt.Errorf("sScan - failed to use service Convention to parse title name: %w", err)
+		}
+		title.Authors = titleNameInfo.Authors
+		title.Circles = titleNameInfo.Circles
+		if titleNameInfo.CreatedAt != nil {
+			title.CreatedAt = *titleNameInfo.CreatedAt
+			title.CreatedAtFromName = true
+		}
+		titles[i] = title
+		titleByTitleURL[title.URL] = title
 	}
 
 	// Scan title covers
-	coverScanChannel := make(chan *titleCoverScanResult, len(titleFolders))
-
-	for _, titleFolder := range titleFolders {
-		titleName := titleFolder.Name()
-		go func(name string) {
-			width, height, err := s.scanTitleCover(filepath.Join(libraryPath, name))
+	coverErrors := make([]*model.ScanError, len(titleFolders))
+	numScannedCovers := int32(0)
+	coverGroup, _ := s.workerPool.NewGroup(ctx, library.GetScanWorkers())
+	for i, title := range titles {
+		i := i
+		title := title
+		coverGroup.Go(func(ctx context.Context) error {
+			width, height, hash, err := s.scanTitleCover(title.URL)
 			if err != nil {
-				zap.L().Error("sScan - failed to scan title cover", zap.Error(err))
-				coverScanChannel <- nil
-			}
-			coverScanChannel <- &titleCoverScanResult{
-				Name:   name,
-				Width:  width,
-				Height: height,
+				zap.L().Warn("sScan - failed to scan title cover", zap.Error(err))
+				coverErrors[i] = newScanError(library, title, filepath.Join(title.URL, "cover.webp"), model.ScanErrorStageCover, err)
+			} else {
+				title.CoverWidth = width
+				title.CoverHeight = height
+				title.CoverHash = &hash
 			}
-		}(titleName)
-	}
 
-	for range titleFolders {
-		coverScanResult := <-coverScanChannel
-		if coverScanResult != nil {
-			title := titleByTitleName[coverScanResult.Name]
-			title.CoverWidth = coverScanResult.Width
-			title.CoverHeight = coverScanResult.Height
-		}
+			publishProgress(&model.ScanEvent{
+				LibraryID: library.ID,
+				Stage:     model.ScanStageCovers,
+				Name:      title.Name,
+				Done:      int(atomic.AddInt32(&numScannedCovers, 1)),
+				Total:     len(titleFolders),
+			})
+			return nil
+		})
+	}
+	err = coverGroup.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("sScan - library scan was stopped while scanning covers: %w", err)
 	}
 
 	// Scan books in each title
-	booksScanChannel := make(chan *titleBooksScanResult, len(titleFolders))
-	booksByTitleName := make(map[string][]*model.Book)
-
-	for _, titleFolder := range titleFolders {
-		titleName := titleFolder.Name()
-		go func(name string) {
-			books := s.scanTitleFolder(filepath.Join(libraryPath, name))
-			booksScanChannel <- &titleBooksScanResult{
-				Name:  name,
-				Books: books,
+	titleBooks := make([][]*model.Book, len(titleFolders))
+	folderErrors := make([]*model.ScanError, len(titleFolders))
+	numScannedFolders := int32(0)
+	folderGroup, _ := s.workerPool.NewGroup(ctx, library.GetScanWorkers())
+	for i, title := range titles {
+		i := i
+		title := title
+		folderGroup.Go(func(ctx context.Context) error {
+			books, err := s.scanTitleFolder(ctx, patterns, options, path.Join(title.GroupPath, title.Name), title.URL)
+			if err != nil {
+				// Failed titles are kept out of the scan result but not reported as removed
+				zap.L().Error("sScan - failed to scan title folder", zap.Error(err))
+				folderErrors[i] = newScanError(library, title, title.URL, model.ScanErrorStageFolder, err)
+			} else {
+				titleBooks[i] = books
+				s.scanTitleBooksInfo(conventionName, title, books)
 			}
-		}(titleName)
-	}
-
-	for range titleFolders {
-		booksScanResult := <-booksScanChannel
-		titleBooks := booksScanResult.Books
-		title := titleByTitleName[booksScanResult.Name]
-
-		// Set number of books in title
-		title.BookCount = len(titleBooks)
-
-		// Set flags for title
-		for _, book := range titleBooks {
-			bookName := book.Name
-			if strings.HasSuffix(bookName, "]") {
-				openedBracketIndex := strings.LastIndex(bookName, "[")
-				if openedBracketIndex > 0 {
-					flags := strings.Split(bookName[(openedBracketIndex+1):(len(bookName)-1)], ",")
-					for _, flag := range flags {
-						flag = strings.TrimSpace(flag)
-						if flag == "Uncensored" || flag == "Decensored" {
-							title.Uncensored = 1
-						} else if flag == "Waifu2x" {
-							title.Waifu2x = 1
-						}
-					}
-				}
+
+			publishProgress(&model.ScanEvent{
+				LibraryID: library.ID,
+				Stage:     model.ScanStageFolders,
+				Name:      title.Name,
+				Done:      int(atomic.AddInt32(&numScannedFolders, 1)),
+				Total:     len(titleFolders),
+			})
+			return nil
+		})
+	}
+	err = folderGroup.Wait()
+	if err != nil {
+		return nil, fmt.Errorf("sScan - library scan was stopped while scanning title folders: %w", err)
+	}
+
+	booksByTitleURL := make(map[string][]*model.Book)
+	errorsByTitleURL := make(map[string][]*model.ScanError)
+	for i, title := range titles {
+		booksByTitleURL[title.URL] = titleBooks[i]
+		for _, scanError := range []*model.ScanError{coverErrors[i], folderErrors[i]} {
+			if scanError != nil {
+				errorsByTitleURL[title.URL] = append(errorsByTitleURL[title.URL], scanError)
 			}
 		}
+	}
 
-		// Scan title's supported languages
-		langsSet := make(map[string]bool)
-		for _, book := range titleBooks {
-			bookName := book.Name
-			openedBracketIndex := strings.Index(bookName, "[")
-			closedBracketIndex := strings.Index(bookName, "]")
-			if openedBracketIndex == 0 && closedBracketIndex != -1 {
-				lang := bookName[(openedBracketIndex + 1):closedBracketIndex]
-				langsSet[lang] = true
-			} else {
-				langsSet["jp"] = true
+	return &model.ScanResult{
+		TitleByTitleURL:  titleByTitleURL,
+		BooksByTitleURL:  booksByTitleURL,
+		ErrorsByTitleURL: errorsByTitleURL,
+	}, nil
+}
+
+type titleFolder struct {
+	path      string
+	groupPath string
+	info      fs.FileInfo
+}
+
+// findTitleFolders walks down the library folders to the given depth where title folders are,
+// names of the folders above them make up the group path of their titles
+func (s *serviceScanner) findTitleFolders(patterns *scanPatterns, folderPath string, groupPath string, depth int) ([]*titleFolder, error) {
+	files, err := os.ReadDir(folderPath)
+	if err != nil {
+		return nil, fmt.Errorf("sScan - failed to read library folder: %w", err)
+	}
+
+	titleFolders := []*titleFolder{}
+	for _, file := range files {
+		fileInfo, err := file.Info()
+		if err != nil || !fileInfo.IsDir() {
+			continue
+		}
+
+		filePath := filepath.Join(folderPath, file.Name())
+		relativePath := path.Join(groupPath, file.Name())
+		if depth <= 1 {
+			if patterns.skipsTitle(relativePath) {
+				continue
 			}
+			titleFolders = append(titleFolders, &titleFolder{
+				path:      filePath,
+				groupPath: groupPath,
+				info:      fileInfo,
+			})
+			continue
 		}
-		langs := []string{}
-		for lang := range langsSet {
-			langs = append(langs, lang)
+
+		if patterns.skipsFolder(relativePath) {
+			continue
 		}
-		sort.Strings(langs)
-		title.Langs = strings.Join(langs, ",")
 
-		booksByTitleName[booksScanResult.Name] = booksScanResult.Books
+		// Unreadable group folders fail the whole scan so that their titles are not taken as removed
+		nestedTitleFolders, err := s.findTitleFolders(patterns, filePath, relativePath, depth-1)
+		if err != nil {
+			return nil, err
+		}
+		titleFolders = append(titleFolders, nestedTitleFolders...)
 	}
 
-	return &model.ScanResult{
-		TitleByTitleName: titleByTitleName,
-		BooksByTitleName: booksByTitleName,
-	}, nil
+	return titleFolders, nil
+}
+
+func newScanError(library *model.Library, title *model.Title, path string, stage string, err error) *model.ScanError {
+	return &model.ScanError{
+		Path:      path,
+		TitleURL:  title.URL,
+		Stage:     stage,
+		Message:   err.Error(),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		LibraryID: library.ID,
+	}
+}
+
+// scanTitleBooksInfo sets the title info derived from the names of its books
+func (s *serviceScanner) scanTitleBooksInfo(conventionName string, title *model.Title, titleBooks []*model.Book) {
+	// Set number of books in title
+	title.BookCount = len(titleBooks)
+
+	// Set tags and supported languages for title from the ones of its books
+	langsSet := make(map[string]bool)
+	tagsSet := make(map[string]bool)
+	for _, book := range titleBooks {
+		book.Tags = []string{}
+		bookNameInfo, err := s.serviceConvention.ParseBookName(conventionName, book.Name)
+		if err != nil {
+			zap.L().Error("sScan - failed to use service Convention to parse book name", zap.Error(err))
+			continue
+		}
+
+		book.Tags = bookNameInfo.Flags
+		for _, tag := range book.Tags {
+			tagsSet[tag] = true
+		}
+		langsSet[bookNameInfo.Lang] = true
+	}
+	langs := []string{}
+	for lang := range langsSet {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	title.Langs = strings.Join(langs, ",")
+	title.Tags = []string{}
+	for tag := range tagsSet {
+		title.Tags = append(title.Tags, tag)
+	}
+	sort.Strings(title.Tags)
 }
 
-func (s *serviceScanner) scanTitleCover(titleFolderPath string) (int, int, error) {
+func (s *serviceScanner) scanTitleCover(titleFolderPath string) (int, int, string, error) {
 	titleCoverPath := filepath.Join(titleFolderPath, "cover.webp")
 	titleCoverFile, err := os.Open(titleCoverPath)
 	if err != nil {
-		return 0, 0, fmt.Errorf("sScan - failed to open cover file: %w", err)
+		return 0, 0, "", fmt.Errorf("sScan - failed to open cover file: %w", err)
+	}
+	defer titleCoverFile.Close()
+
+	coverData, err := io.ReadAll(titleCoverFile)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("sScan - failed to read cover file: %w", err)
+	}
+
+	// Hash of the cover helps recognizing the title after its folder is renamed
+	coverHash := sha256.Sum256(coverData)
+	width, height, err := s.serviceImage.GetDimensions(bytes.NewReader(coverData))
+	if err != nil {
+		return 0, 0, "", err
 	}
 
-	return s.serviceImage.GetDimensions(titleCoverFile)
+	return width, height, hex.EncodeToString(coverHash[:]), nil
 }
 
-func (s *serviceScanner) scanTitleFolder(titleFolderPath string) []*model.Book {
+func (s *serviceScanner) scanTitleFolder(ctx context.Context, patterns *scanPatterns, options *model.ScanOptions, titleRelativePath string, titleFolderPath string) ([]*model.Book, error) {
 	files, err := os.ReadDir(titleFolderPath)
 	if err != nil {
-		zap.L().Error("sScan - failed to read title folder", zap.Error(err))
-		return nil
+		return nil, fmt.Errorf("sScan - failed to read title folder: %w", err)
 	}
 
 	books := []*model.Book{}
@@ -208,12 +311,13 @@ func (s *serviceScanner) scanTitleFolder(titleFolderPath string) []*model.Book {
 
 		fileName := file.Name()
 		fileExtension := filepath.Ext(fileName)
+		if patterns.skipsFile(path.Join(titleRelativePath, fileName)) {
+			continue
+		}
 		if fileExtension == ".cbz" {
 			fileNameWithoutExtension := strings.Replace(fileName, fileExtension, "", -1)
 			bookFilePath := filepath.Join(titleFolderPath, fileName)
 			bookLastModifiedTime := fileInfo.ModTime().UTC().Format(time.RFC3339)
-			pageCount, _ := s.serviceArchive.GetFilesCount(bookFilePath)
-
 			var previewURL *string
 			var previewUpdatedAt *string
 			previewFilePath := filepath.Join(titleFolderPath, fmt.Sprintf("%s - Preview.zip", fileNameWithoutExtension))
@@ -231,11 +335,35 @@ func (s *serviceScanner) scanTitleFolder(titleFolderPath string) []*model.Book {
 				UpdatedAt:        bookLastModifiedTime,
 				PreviewURL:       previewURL,
 				PreviewUpdatedAt: previewUpdatedAt,
-				PageCount:        pageCount,
+			}
+
+			// Archives not modified since they were stored keep the page count and fingerprint in DB,
+			// so that unchanged books are compared without being opened
+			knownBook := options.KnownBookByURL[bookFilePath]
+			if knownBook != nil && knownBook.UpdatedAt == bookLastModifiedTime && knownBook.Fingerprint != nil {
+				book.PageCount = knownBook.PageCount
+				book.Fingerprint = knownBook.Fingerprint
+				books = append(books, book)
+				continue
+			}
+
+			// Probe is kept on the book so that scanning its pages does not read its archive again,
+			// dimensions are only read for changed content and never for dry runs which do not scan pages
+			var knownFingerprint *string
+			if knownBook != nil {
+				knownFingerprint = knownBook.Fingerprint
+			}
+			probe, err := s.serviceArchive.ProbeBook(ctx, book, !options.DryRun, knownFingerprint)
+			if err != nil {
+				zap.L().Error("sScan - failed to use service Archive to probe book", zap.String("url", bookFilePath), zap.Error(err))
+			} else {
+				book.PageCount = len(probe.Pages)
+				book.Fingerprint = &probe.Fingerprint
+				book.Probe = probe
 			}
 			books = append(books, book)
 		}
 	}
 
-	return books
+	return books, nil
 }
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/robfig/cron/v3"
+	"go.uber.org/zap"
+)
+
+type ServiceScanScheduler interface {
+	Start(sqlite.DB) error
+	Schedule(sqlite.DB, *model.Library) error
+	Unschedule(int64)
+}
+
+type serviceScanScheduler struct {
+	serviceLibrary ServiceLibrary
+	serviceScanJob ServiceScanJob
+
+	mutex                    sync.Mutex
+	scheduledScanByLibraryID map[int64]*scheduledScan
+}
+
+type scheduledScan struct {
+	timer *time.Timer
+	// library is the one the scans were scheduled for, its schedule is kept when the library cannot be read again
+	library *model.Library
+}
+
+func NewServiceScanScheduler(sLibrary ServiceLibrary, sScanJob ServiceScanJob) ServiceScanScheduler {
+	return &serviceScanScheduler{
+		serviceLibrary:           sLibrary,
+		serviceScanJob:           sScanJob,
+		scheduledScanByLibraryID: make(map[int64]*scheduledScan),
+	}
+}
+
+func (s *serviceScanScheduler) Start(db sqlite.DB) error {
+	libraries, err := s.serviceLibrary.GetLibraries(context.Background(), db)
+	if err != nil {
+		return fmt.Errorf("sScanScheduler - failed to use service Library to get libraries: %w", err)
+	}
+
+	for _, library := range libraries {
+		err = s.Schedule(db, library)
+		if err != nil {
+			zap.L().Error("sScanScheduler - failed to schedule library scans", zap.Int64("libraryID", library.ID), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// Schedule (re)arms the scheduled scans of a library from its current schedule
+// and stores the time of the next run
+func (s *serviceScanScheduler) Schedule(db sqlite.DB, library *model.Library) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.unschedule(library.ID)
+
+	var nextRunAt *string
+	if library.GetScanSchedule() != "" {
+		schedule, err := parseScanSchedule(library.GetScanSchedule())
+		if err != nil {
+			return fmt.Errorf("sScanScheduler - failed to parse scan schedule of library: %w", err)
+		}
+
+		next := schedule.Next(time.Now())
+		nextString := next.UTC().Format(time.RFC3339)
+		nextRunAt = &nextString
+
+		libraryID := library.ID
+		ss := &scheduledScan{library: library}
+		ss.timer = time.AfterFunc(time.Until(next), func() {
+			s.runScheduledScan(db, libraryID, ss)
+		})
+		s.scheduledScanByLibraryID[libraryID] = ss
+	}
+
+	library.ScanNextRunAt = nextRunAt
+	err := s.serviceLibrary.UpdateLibraryScanRunTimes(context.Background(), db, library)
+	if err != nil {
+		return fmt.Errorf("sScanScheduler - failed to use service Library to store next scan run time: %w", err)
+	}
+
+	return nil
+}
+
+func (s *serviceScanScheduler) Unschedule(libraryID int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.unschedule(libraryID)
+}
+
+func (s *serviceScanScheduler) unschedule(libraryID int64) {
+	if ss, ok := s.scheduledScanByLibraryID[libraryID]; ok {
+		ss.timer.Stop()
+		delete(s.scheduledScanByLibraryID, libraryID)
+	}
+}
+
+func (s *serviceScanScheduler) runScheduledScan(db sqlite.DB, libraryID int64, ss *scheduledScan) {
+	// Runs superseded by a newer schedule of the library are dropped
+	s.mutex.Lock()
+	current := s.scheduledScanByLibraryID[libraryID] == ss
+	s.mutex.Unlock()
+	if !current {
+		return
+	}
+
+	ctx := context.Background()
+	library, err := s.serviceLibrary.GetLibraryByID(ctx, db, fmt.Sprintf("%d", libraryID))
+	if errors.Is(err, model.ErrNotFound) {
+		s.Unschedule(libraryID)
+		return
+	} else if err != nil {
+		zap.L().Error("sScanScheduler - failed to use service Library to get scheduled library, next scan is scheduled from its previous schedule", zap.Int64("libraryID", libraryID), zap.Error(err))
+		library = ss.library
+	} else {
+		job, started, err := s.serviceScanJob.StartScan(db, library)
+		if err != nil {
+			zap.L().Error("sScanScheduler - failed to use service ScanJob to start scheduled library scan", zap.Int64("libraryID", libraryID), zap.Error(err))
+		} else if !started {
+			zap.L().Info("sScanScheduler - skipped scheduled library scan as a scan is already running", zap.Int64("libraryID", libraryID), zap.String("jobID", job.ID))
+		} else {
+			zap.L().Info("sScanScheduler - started scheduled library scan", zap.Int64("libraryID", libraryID), zap.String("jobID", job.ID))
+			library.ScanLastRunAt = &job.StartedAt
+		}
+	}
+
+	s.mutex.Lock()
+	current = s.scheduledScanByLibraryID[libraryID] == ss
+	s.mutex.Unlock()
+	if !current {
+		return
+	}
+
+	err = s.Schedule(db, library)
+	if err != nil {
+		zap.L().Error("sScanScheduler - failed to schedule next library scan", zap.Int64("libraryID", libraryID), zap.Error(err))
+	}
+}
+
+// parseScanSchedule accepts standard cron expressions, descriptors such as "@daily"
+// or "@every 6h", and plain intervals such as "6h"
+func parseScanSchedule(spec string) (cron.Schedule, error) {
+	interval, err := time.ParseDuration(spec)
+	if err == nil {
+		if interval < time.Second {
+			return nil, fmt.Errorf("%w: scan interval must be at least one second", model.ErrBadRequest)
+		}
+		return cron.Every(interval), nil
+	}
+
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid scan schedule: %s", model.ErrBadRequest, err.Error())
+	}
+
+	return schedule, nil
+}
+
+// validateScanSchedule checks the scan schedule of a library
+// and clears it when it is left empty
+func validateScanSchedule(library *model.Library) error {
+	if library.GetScanSchedule() == "" {
+		library.ScanSchedule = nil
+		return nil
+	}
+
+	_, err := parseScanSchedule(library.GetScanSchedule())
+	return err
+}
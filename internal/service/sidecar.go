@@ -0,0 +1,180 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/imouto1994/yume/internal/model"
+)
+
+type ServiceSidecar interface {
+	ReadBookSidecar(*model.Book) (*model.BookSidecar, error)
+	WriteBookSidecar(*model.Book, *model.BookSidecar) error
+	MoveBookSidecar(*model.Book, *model.Book) error
+}
+
+type serviceSidecar struct {
+}
+
+func NewServiceSidecar() ServiceSidecar {
+	return &serviceSidecar{}
+}
+
+// ReadBookSidecar reads the sidecar of a book, nil is returned when the book does not have any.
+// Sidecars of older versions are converted to the current format
+func (s *serviceSidecar) ReadBookSidecar(book *model.Book) (*model.BookSidecar, error) {
+	data, err := os.ReadFile(getBookSidecarPath(book))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("sSidecar - failed to read book sidecar file: %w", err)
+	}
+
+	// Sidecars written before the format was versioned are lists of favorite page numbers
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '[' {
+		favoritePages := []int{}
+		err = json.Unmarshal(data, &favoritePages)
+		if err != nil {
+			return nil, fmt.Errorf("sSidecar - failed to parse unversioned book sidecar file: %w", err)
+		}
+
+		return &model.BookSidecar{FavoritePages: newBookSidecarPages(favoritePages)}, nil
+	}
+
+	var versioned struct {
+		Version int `json:"version"`
+	}
+	err = json.Unmarshal(data, &versioned)
+	if err != nil {
+		return nil, fmt.Errorf("sSidecar - failed to parse book sidecar file: %w", err)
+	}
+	if versioned.Version > model.BookSidecarVersion {
+		return nil, fmt.Errorf("sSidecar - book sidecar file has unsupported version %d", versioned.Version)
+	}
+
+	// Sidecars of version 1 hold favorite page numbers only
+	if versioned.Version < 2 {
+		var sidecarV1 struct {
+			FavoritePages []int `json:"favorite_pages"`
+		}
+		err = json.Unmarshal(data, &sidecarV1)
+		if err != nil {
+			return nil, fmt.Errorf("sSidecar - failed to parse book sidecar file of version %d: %w", versioned.Version, err)
+		}
+
+		return &model.BookSidecar{Version: versioned.Version, FavoritePages: newBookSidecarPages(sidecarV1.FavoritePages)}, nil
+	}
+
+	var sidecar model.BookSidecar
+	err = json.Unmarshal(data, &sidecar)
+	if err != nil {
+		return nil, fmt.Errorf("sSidecar - failed to parse book sidecar file: %w", err)
+	}
+
+	return &sidecar, nil
+}
+
+func (s *serviceSidecar) WriteBookSidecar(book *model.Book, sidecar *model.BookSidecar) error {
+	sidecar.Version = model.BookSidecarVersion
+	data, err := json.MarshalIndent(sidecar, "", " ")
+	if err != nil {
+		return fmt.Errorf("sSidecar - failed to serialize book sidecar: %w", err)
+	}
+
+	// Modified time of the title folder is put back after writing, so that sidecars do not make scans find the title changed
+	sidecarPath := getBookSidecarPath(book)
+	folderPath := filepath.Dir(sidecarPath)
+	folderInfo, err := os.Stat(folderPath)
+	if err != nil {
+		return fmt.Errorf("sSidecar - failed to get file info of book folder: %w", err)
+	}
+
+	// Write to a temporary file of its own first, so that readers never see a partially written sidecar
+	// and concurrent writes do not write into the same file
+	tempFile, err := os.CreateTemp(folderPath, filepath.Base(sidecarPath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("sSidecar - failed to create temporary book sidecar file: %w", err)
+	}
+	tempPath := tempFile.Name()
+	_, err = tempFile.Write(data)
+	if err == nil {
+		err = tempFile.Chmod(0644)
+	}
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("sSidecar - failed to write book sidecar file: %w", err)
+	}
+	err = os.Rename(tempPath, sidecarPath)
+	if err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("sSidecar - failed to replace book sidecar file: %w", err)
+	}
+
+	return restoreFolderModifiedTime(folderPath, folderInfo)
+}
+
+// MoveBookSidecar moves the sidecar of a renamed or moved book next to its new location,
+// so that another book taking the previous name does not inherit its favorites
+func (s *serviceSidecar) MoveBookSidecar(book *model.Book, movedBook *model.Book) error {
+	sidecarPath := getBookSidecarPath(book)
+	movedSidecarPath := getBookSidecarPath(movedBook)
+	if sidecarPath == movedSidecarPath {
+		return nil
+	}
+	if _, err := os.Stat(sidecarPath); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	folderPath := filepath.Dir(sidecarPath)
+	folderInfo, err := os.Stat(folderPath)
+	if err != nil {
+		return fmt.Errorf("sSidecar - failed to get file info of book folder: %w", err)
+	}
+	movedFolderPath := filepath.Dir(movedSidecarPath)
+	movedFolderInfo, err := os.Stat(movedFolderPath)
+	if err != nil {
+		return fmt.Errorf("sSidecar - failed to get file info of moved book folder: %w", err)
+	}
+
+	err = os.Rename(sidecarPath, movedSidecarPath)
+	if err != nil {
+		return fmt.Errorf("sSidecar - failed to move book sidecar file: %w", err)
+	}
+
+	err = restoreFolderModifiedTime(folderPath, folderInfo)
+	if err != nil {
+		return err
+	}
+
+	return restoreFolderModifiedTime(movedFolderPath, movedFolderInfo)
+}
+
+func restoreFolderModifiedTime(folderPath string, folderInfo fs.FileInfo) error {
+	err := os.Chtimes(folderPath, folderInfo.ModTime(), folderInfo.ModTime())
+	if err != nil {
+		return fmt.Errorf("sSidecar - failed to restore modified time of book folder: %w", err)
+	}
+
+	return nil
+}
+
+func newBookSidecarPages(numbers []int) []*model.BookSidecarPage {
+	pages := make([]*model.BookSidecarPage, len(numbers))
+	for i, number := range numbers {
+		pages[i] = &model.BookSidecarPage{Number: number}
+	}
+
+	return pages
+}
+
+func getBookSidecarPath(book *model.Book) string {
+	return filepath.Join(filepath.Dir(book.URL), fmt.Sprintf("%s.json", book.Name))
+}
@@ -34,7 +34,7 @@ func NewServiceSubtitle(sLibrary ServiceLibrary, sBook ServiceBook, sTitle Servi
 }
 
 func (s *serviceSubtitle) CreateSubtitle(ctx context.Context, dbOps sqlite.DBOps, subtitle *model.Subtitle) error {
-	library, err := s.serviceLibrary.GetLibraryByID(ctx, dbOps, subtitle.LibraryID)
+	_, err := s.serviceLibrary.GetLibraryByID(ctx, dbOps, subtitle.LibraryID)
 	if err != nil {
 		return fmt.Errorf("sSubtitle - failed to use service Library to get library of subtitle: %w", err)
 	}
@@ -47,7 +47,8 @@ func (s *serviceSubtitle) CreateSubtitle(ctx context.Context, dbOps sqlite.DBOps
 		return fmt.Errorf("sSubtitle - failed to use service Title to get title of subtitle: %w", err)
 	}
 
-	libraryFolder := library.Root
+	// Subtitles are created next to their title, within the same group folder
+	groupFolder := filepath.Dir(title.URL)
 
 	// Determine subtitle name
 	titleName := title.Name
@@ -56,7 +57,7 @@ func (s *serviceSubtitle) CreateSubtitle(ctx context.Context, dbOps sqlite.DBOps
 	subtitleName := fmt.Sprintf("[%s (%s)] %s {%s}", subtitle.Author, titleMain, subtitle.Name, titleDate)
 
 	// Create subtitle folder
-	subtitleFolderPath := filepath.Join(libraryFolder, subtitleName)
+	subtitleFolderPath := filepath.Join(groupFolder, subtitleName)
 	os.MkdirAll(subtitleFolderPath, os.ModePerm)
 
 	// Determine subtitle book lang
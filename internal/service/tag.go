@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/imouto1994/yume/internal/repository"
+)
+
+type ServiceTag interface {
+	GetTags(context.Context, sqlite.DBOps) ([]*model.Tag, error)
+	GetOrCreateTags(context.Context, sqlite.DBOps, []string) ([]*model.Tag, error)
+}
+
+type serviceTag struct {
+	repositoryTag repository.RepositoryTag
+}
+
+func NewServiceTag(rTag repository.RepositoryTag) ServiceTag {
+	return &serviceTag{
+		repositoryTag: rTag,
+	}
+}
+
+func (s *serviceTag) GetTags(ctx context.Context, dbOps sqlite.DBOps) ([]*model.Tag, error) {
+	tags, err := s.repositoryTag.FindAll(ctx, dbOps)
+	if err != nil {
+		return nil, fmt.Errorf("sTag - failed to find all tags in DB: %w", err)
+	}
+
+	return tags, nil
+}
+
+// GetOrCreateTags returns the tags with given names, creating the ones which do not exist yet
+func (s *serviceTag) GetOrCreateTags(ctx context.Context, dbOps sqlite.DBOps, names []string) ([]*model.Tag, error) {
+	for _, name := range names {
+		err := s.repositoryTag.InsertIfNotExists(ctx, dbOps, name)
+		if err != nil {
+			return nil, fmt.Errorf("sTag - failed to create tag in DB: %w", err)
+		}
+	}
+
+	tags, err := s.repositoryTag.FindAllByNames(ctx, dbOps, names)
+	if err != nil {
+		return nil, fmt.Errorf("sTag - failed to find tags with given names in DB: %w", err)
+	}
+
+	return tags, nil
+}
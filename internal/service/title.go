@@ -2,10 +2,13 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/imouto1994/yume/internal/infra/sqlite"
 	"github.com/imouto1994/yume/internal/model"
@@ -15,29 +18,48 @@ import (
 type ServiceTitle interface {
 	CreateTitle(context.Context, sqlite.DBOps, *model.Title) error
 	SearchTitles(context.Context, sqlite.DBOps, *model.TitleQuery) ([]*model.Title, error)
+	SearchTitlesPage(context.Context, sqlite.DBOps, *model.TitleQuery) (*model.TitlePage, error)
 	CountSearchTitles(context.Context, sqlite.DBOps, *model.TitleQuery) (int, error)
 	GetTitleByID(context.Context, sqlite.DBOps, string) (*model.Title, error)
 	GetTitlesByLibraryID(context.Context, sqlite.DBOps, string) ([]*model.Title, error)
 	StreamTitleCoverByID(context.Context, sqlite.DBOps, io.Writer, string) error
+	UpdateTitleLocation(context.Context, sqlite.DBOps, string, string, string, string) error
+	UpdateTitleCreatedTime(context.Context, sqlite.DBOps, string, string) error
 	UpdateTitleModifiedTime(context.Context, sqlite.DBOps, string, string) error
 	UpdateTitleCoverDimension(context.Context, sqlite.DBOps, string, int, int) error
+	UpdateTitleCoverHash(context.Context, sqlite.DBOps, string, *string) error
 	UpdateTitleLangs(context.Context, sqlite.DBOps, string, string) error
 	UpdateTitleBookCount(context.Context, sqlite.DBOps, string, int) error
-	UpdateTitleUncensored(context.Context, sqlite.DBOps, string, int) error
-	UpdateTitleWaifu2x(context.Context, sqlite.DBOps, string, int) error
+	UpdateTitleReadTime(context.Context, sqlite.DBOps, string, string) error
+	UpdateTitleTags(context.Context, sqlite.DBOps, *model.Title) error
+	UpdateTitleAuthors(context.Context, sqlite.DBOps, *model.Title) error
+	UpdateTitleCircles(context.Context, sqlite.DBOps, *model.Title) error
+	IndexTitle(context.Context, sqlite.DBOps, string) error
 	DeleteTitlesByLibraryID(context.Context, sqlite.DBOps, string) error
 	DeleteTitleByID(context.Context, sqlite.DBOps, string) error
 }
 
 type serviceTitle struct {
-	repositoryTitle repository.RepositoryTitle
-	serviceBook     ServiceBook
+	repositoryTitle       repository.RepositoryTitle
+	repositoryTitleSearch repository.RepositoryTitleSearch
+	repositoryTitleTag    repository.RepositoryTitleTag
+	repositoryTitleAuthor repository.RepositoryTitleAuthor
+	repositoryTitleCircle repository.RepositoryTitleCircle
+	serviceBook           ServiceBook
+	serviceTag            ServiceTag
+	serviceAuthor         ServiceAuthor
 }
 
-func NewServiceTitle(rTitle repository.RepositoryTitle, sBook ServiceBook) ServiceTitle {
+func NewServiceTitle(rTitle repository.RepositoryTitle, rTitleSearch repository.RepositoryTitleSearch, rTitleTag repository.RepositoryTitleTag, rTitleAuthor repository.RepositoryTitleAuthor, rTitleCircle repository.RepositoryTitleCircle, sBook ServiceBook, sTag ServiceTag, sAuthor ServiceAuthor) ServiceTitle {
 	return &serviceTitle{
-		repositoryTitle: rTitle,
-		serviceBook:     sBook,
+		repositoryTitle:       rTitle,
+		repositoryTitleSearch: rTitleSearch,
+		repositoryTitleTag:    rTitleTag,
+		repositoryTitleAuthor: rTitleAuthor,
+		repositoryTitleCircle: rTitleCircle,
+		serviceBook:           sBook,
+		serviceTag:            sTag,
+		serviceAuthor:         sAuthor,
 	}
 }
 
@@ -46,20 +68,181 @@ func (s *serviceTitle) CreateTitle(ctx context.Context, dbOps sqlite.DBOps, titl
 	if err != nil {
 		return fmt.Errorf("sTitle - failed to create title in DB: %w", err)
 	}
+	err = s.insertTitleTags(ctx, dbOps, title)
+	if err != nil {
+		return err
+	}
+	err = s.insertTitleAuthors(ctx, dbOps, title)
+	if err != nil {
+		return err
+	}
+	err = s.insertTitleCircles(ctx, dbOps, title)
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
 
 func (s *serviceTitle) SearchTitles(ctx context.Context, dbOps sqlite.DBOps, titleQuery *model.TitleQuery) ([]*model.Title, error) {
+	err := buildTitleQueryFilter(titleQuery)
+	if err != nil {
+		return nil, fmt.Errorf("sTitle - failed to build filter of title search: %w", err)
+	}
+	titleQuery.Sort = normalizeTitleSort(titleQuery)
+	titleQuery.After = nil
+	err = validateTitleOrder(titleQuery.Order)
+	if err != nil {
+		return nil, err
+	}
+
 	titles, err := s.repositoryTitle.Find(ctx, dbOps, titleQuery)
 	if err != nil {
 		return nil, fmt.Errorf("sTitle - failed to search for titles with given queries in DB: %w", err)
 	}
+	err = s.fillTitlesNames(ctx, dbOps, titles)
+	if err != nil {
+		return nil, err
+	}
 
 	return titles, nil
 }
 
+// SearchTitlesPage lists the titles after the cursor of the query, or the first titles without cursor,
+// along with the cursor of the next page which is nil once there are no more titles
+func (s *serviceTitle) SearchTitlesPage(ctx context.Context, dbOps sqlite.DBOps, titleQuery *model.TitleQuery) (*model.TitlePage, error) {
+	err := buildTitleQueryFilter(titleQuery)
+	if err != nil {
+		return nil, fmt.Errorf("sTitle - failed to build filter of title search: %w", err)
+	}
+	titleQuery.Sort = normalizeTitleSort(titleQuery)
+	titleQuery.After = nil
+	err = validateTitleOrder(titleQuery.Order)
+	if err != nil {
+		return nil, err
+	}
+	if titleQuery.Cursor != "" {
+		cursor, err := decodeTitleCursor(titleQuery.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.Sort != titleQuery.Sort || cursor.Order != titleQuery.Order {
+			return nil, fmt.Errorf("sTitle - %w: cursor was created for another sort order", model.ErrBadRequest)
+		}
+		// Pages of a shuffle keep the seed of the first page unless another seed is given
+		if titleQuery.Sort == model.TitleSortRandom {
+			if titleQuery.Seed == "" {
+				titleQuery.Seed = cursor.Seed
+			} else if titleQuery.Seed != cursor.Seed {
+				return nil, fmt.Errorf("sTitle - %w: cursor was created for another seed of random sort", model.ErrBadRequest)
+			}
+		}
+		titleQuery.After = cursor
+	}
+
+	// Look one title ahead to know whether there is a next page
+	size := titleQuery.Size
+	if size < 1 {
+		return nil, fmt.Errorf("sTitle - %w: page size must be positive: %d", model.ErrBadRequest, size)
+	}
+	titleQuery.Page = 0
+	titleQuery.Size = size + 1
+	titles, err := s.repositoryTitle.Find(ctx, dbOps, titleQuery)
+	titleQuery.Size = size
+	if err != nil {
+		return nil, fmt.Errorf("sTitle - failed to search for titles with given queries in DB: %w", err)
+	}
+
+	page := &model.TitlePage{}
+	if len(titles) > size {
+		titles = titles[:size]
+		last := titles[len(titles)-1]
+		cursor := &model.TitleCursor{Sort: titleQuery.Sort, Order: titleQuery.Order, Key: last.SortKey, ID: last.ID}
+		if titleQuery.Sort == model.TitleSortRandom {
+			cursor.Seed = titleQuery.Seed
+		}
+		nextCursor, err := encodeTitleCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = &nextCursor
+	}
+	err = s.fillTitlesNames(ctx, dbOps, titles)
+	if err != nil {
+		return nil, err
+	}
+	page.Titles = titles
+
+	return page, nil
+}
+
+// normalizeTitleSort gives the sort which titles of the query are ordered by,
+// search results are ordered by relevance and other titles by created time unless another order is requested
+func normalizeTitleSort(titleQuery *model.TitleQuery) string {
+	hasSearch := strings.TrimSpace(titleQuery.Search) != ""
+	switch titleQuery.Sort {
+	case model.TitleSortName, model.TitleSortUpdatedAt, model.TitleSortCreatedAt, model.TitleSortBookCount, model.TitleSortPageCount,
+		model.TitleSortFavoriteCount, model.TitleSortReadAt, model.TitleSortRandom:
+		return titleQuery.Sort
+	case "", model.TitleSortRelevance:
+		if hasSearch {
+			return model.TitleSortRelevance
+		}
+	}
+
+	return model.TitleSortCreatedAt
+}
+
+// validateTitleOrder checks the direction of a sort, each sort has its own direction when none is given
+func validateTitleOrder(order string) error {
+	switch order {
+	case "", model.TitleOrderAscending, model.TitleOrderDescending:
+		return nil
+	}
+
+	return fmt.Errorf("sTitle - %w: order of titles must be %s or %s", model.ErrBadRequest, model.TitleOrderAscending, model.TitleOrderDescending)
+}
+
+// encodeTitleCursor encodes the cursor into an opaque string which is safe to be put in URLs
+func encodeTitleCursor(cursor *model.TitleCursor) (string, error) {
+	if key, ok := cursor.Key.([]byte); ok {
+		cursor.Key = string(key)
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", fmt.Errorf("sTitle - failed to encode cursor of title search: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeTitleCursor(value string) (*model.TitleCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("sTitle - %w: cursor of title search is malformed", model.ErrBadRequest)
+	}
+
+	cursor := &model.TitleCursor{}
+	err = json.Unmarshal(data, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("sTitle - %w: cursor of title search is malformed", model.ErrBadRequest)
+	}
+	switch cursor.Key.(type) {
+	case string, float64:
+	default:
+		return nil, fmt.Errorf("sTitle - %w: cursor of title search has invalid sort key", model.ErrBadRequest)
+	}
+
+	return cursor, nil
+}
+
 func (s *serviceTitle) CountSearchTitles(ctx context.Context, dbOps sqlite.DBOps, titleQuery *model.TitleQuery) (int, error) {
+	err := buildTitleQueryFilter(titleQuery)
+	if err != nil {
+		return 0, fmt.Errorf("sTitle - failed to build filter of title search: %w", err)
+	}
+
 	count, err := s.repositoryTitle.GetTotalFindResults(ctx, dbOps, titleQuery)
 	if err != nil {
 		return 0, fmt.Errorf("sTitle - failed to count total results of title search with given queries in DB: %w", err)
@@ -68,11 +251,60 @@ func (s *serviceTitle) CountSearchTitles(ctx context.Context, dbOps sqlite.DBOps
 	return count, nil
 }
 
+// buildTitleQueryFilter combines the libraries, search text, tags, authors and filter of a title query
+// into the filter node which the query is compiled from, so that listing and counting match the same titles
+func buildTitleQueryFilter(titleQuery *model.TitleQuery) error {
+	nodes := []model.FilterNode{}
+	if len(titleQuery.LibraryIDs) > 0 {
+		libraryNodes := []model.FilterNode{}
+		for _, libraryID := range titleQuery.LibraryIDs {
+			node, err := newFilterNode(model.FilterFieldLibrary, model.FilterOperatorEqual, libraryID)
+			if err != nil {
+				return err
+			}
+			libraryNodes = append(libraryNodes, node)
+		}
+		nodes = append(nodes, &model.FilterOr{Nodes: libraryNodes})
+	}
+	if strings.TrimSpace(titleQuery.Search) != "" {
+		nodes = append(nodes, &model.FilterTerm{Field: model.FilterFieldText, Operator: model.FilterOperatorEqual, Value: titleQuery.Search})
+	}
+	for _, tag := range titleQuery.Tags {
+		nodes = append(nodes, &model.FilterTerm{Field: model.FilterFieldTag, Operator: model.FilterOperatorEqual, Value: tag})
+	}
+	for _, authorID := range titleQuery.AuthorIDs {
+		node, err := newFilterNode(model.FilterFieldAuthorID, model.FilterOperatorEqual, authorID)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, node)
+	}
+
+	filterNode, err := parseTitleFilter(titleQuery.Filter)
+	if err != nil {
+		return err
+	}
+	if filterNode != nil {
+		nodes = append(nodes, filterNode)
+	}
+
+	titleQuery.FilterNode = nil
+	if len(nodes) > 0 {
+		titleQuery.FilterNode = &model.FilterAnd{Nodes: nodes}
+	}
+
+	return nil
+}
+
 func (s *serviceTitle) GetTitleByID(ctx context.Context, dbOps sqlite.DBOps, titleID string) (*model.Title, error) {
 	title, err := s.repositoryTitle.FindByID(ctx, dbOps, titleID)
 	if err != nil {
 		return nil, fmt.Errorf("sTitle - failed to find title with given ID in DB: %w", err)
 	}
+	err = s.fillTitlesNames(ctx, dbOps, []*model.Title{title})
+	if err != nil {
+		return nil, err
+	}
 
 	return title, nil
 }
@@ -82,10 +314,32 @@ func (s *serviceTitle) GetTitlesByLibraryID(ctx context.Context, dbOps sqlite.DB
 	if err != nil {
 		return nil, fmt.Errorf("sTitle - failed to find all titles with given library ID in DB: %w", err)
 	}
+	err = s.fillTitlesNames(ctx, dbOps, titles)
+	if err != nil {
+		return nil, err
+	}
 
 	return titles, nil
 }
 
+func (s *serviceTitle) UpdateTitleLocation(ctx context.Context, dbOps sqlite.DBOps, titleID string, name string, url string, groupPath string) error {
+	err := s.repositoryTitle.UpdateLocation(ctx, dbOps, titleID, name, url, groupPath)
+	if err != nil {
+		return fmt.Errorf("sTitle - failed to update title's name, URL and group path with given title ID in DB: %w", err)
+	}
+
+	return nil
+}
+
+func (s *serviceTitle) UpdateTitleCreatedTime(ctx context.Context, dbOps sqlite.DBOps, titleID string, createdTime string) error {
+	err := s.repositoryTitle.UpdateCreatedTime(ctx, dbOps, titleID, createdTime)
+	if err != nil {
+		return fmt.Errorf("sTitle - failed to update title's created time with given title ID in DB: %w", err)
+	}
+
+	return nil
+}
+
 func (s *serviceTitle) UpdateTitleModifiedTime(ctx context.Context, dbOps sqlite.DBOps, titleID string, modTime string) error {
 	err := s.repositoryTitle.UpdateModifiedTime(ctx, dbOps, titleID, modTime)
 	if err != nil {
@@ -104,6 +358,15 @@ func (s *serviceTitle) UpdateTitleCoverDimension(ctx context.Context, dbOps sqli
 	return nil
 }
 
+func (s *serviceTitle) UpdateTitleCoverHash(ctx context.Context, dbOps sqlite.DBOps, titleID string, coverHash *string) error {
+	err := s.repositoryTitle.UpdateCoverHash(ctx, dbOps, titleID, coverHash)
+	if err != nil {
+		return fmt.Errorf("sTitle - failed to update title's cover hash with given title ID in DB: %w", err)
+	}
+
+	return nil
+}
+
 func (s *serviceTitle) UpdateTitleLangs(ctx context.Context, dbOps sqlite.DBOps, titleID string, langs string) error {
 	err := s.repositoryTitle.UpdateLangs(ctx, dbOps, titleID, langs)
 	if err != nil {
@@ -122,41 +385,167 @@ func (s *serviceTitle) UpdateTitleBookCount(ctx context.Context, dbOps sqlite.DB
 	return nil
 }
 
-func (s *serviceTitle) UpdateTitleUncensored(ctx context.Context, dbOps sqlite.DBOps, titleID string, uncensored int) error {
-	err := s.repositoryTitle.UpdateUncensored(ctx, dbOps, titleID, uncensored)
+func (s *serviceTitle) UpdateTitleReadTime(ctx context.Context, dbOps sqlite.DBOps, titleID string, readTime string) error {
+	err := s.repositoryTitle.UpdateReadTime(ctx, dbOps, titleID, readTime)
+	if err != nil {
+		return fmt.Errorf("sTitle - failed to update title's read time with given title ID in DB: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTitleTags replaces the tags of a title with the ones it currently has
+func (s *serviceTitle) UpdateTitleTags(ctx context.Context, dbOps sqlite.DBOps, title *model.Title) error {
+	err := s.repositoryTitleTag.DeleteAllByTitleID(ctx, dbOps, fmt.Sprintf("%d", title.ID))
+	if err != nil {
+		return fmt.Errorf("sTitle - failed to delete title tags with given title ID in DB: %w", err)
+	}
+
+	return s.insertTitleTags(ctx, dbOps, title)
+}
+
+// UpdateTitleAuthors replaces the authors of a title with the ones it currently has
+func (s *serviceTitle) UpdateTitleAuthors(ctx context.Context, dbOps sqlite.DBOps, title *model.Title) error {
+	err := s.repositoryTitleAuthor.DeleteAllByTitleID(ctx, dbOps, fmt.Sprintf("%d", title.ID))
+	if err != nil {
+		return fmt.Errorf("sTitle - failed to delete title authors with given title ID in DB: %w", err)
+	}
+
+	return s.insertTitleAuthors(ctx, dbOps, title)
+}
+
+// UpdateTitleCircles replaces the circles of a title with the ones it currently has
+func (s *serviceTitle) UpdateTitleCircles(ctx context.Context, dbOps sqlite.DBOps, title *model.Title) error {
+	err := s.repositoryTitleCircle.DeleteAllByTitleID(ctx, dbOps, fmt.Sprintf("%d", title.ID))
+	if err != nil {
+		return fmt.Errorf("sTitle - failed to delete title circles with given title ID in DB: %w", err)
+	}
+
+	return s.insertTitleCircles(ctx, dbOps, title)
+}
+
+// IndexTitle updates the full-text search entry of a title once its name, books or tags changed
+func (s *serviceTitle) IndexTitle(ctx context.Context, dbOps sqlite.DBOps, titleID string) error {
+	err := s.repositoryTitleSearch.Refresh(ctx, dbOps, titleID)
+	if err != nil {
+		return fmt.Errorf("sTitle - failed to update search entry of title in DB: %w", err)
+	}
+
+	return nil
+}
+
+func (s *serviceTitle) insertTitleTags(ctx context.Context, dbOps sqlite.DBOps, title *model.Title) error {
+	tags, err := s.serviceTag.GetOrCreateTags(ctx, dbOps, title.Tags)
 	if err != nil {
-		return fmt.Errorf("sTitle - failed to update title's uncensored flag with given title ID in DB: %w", err)
+		return fmt.Errorf("sTitle - failed to use service Tag to get tags of title: %w", err)
+	}
+	for _, tag := range tags {
+		err = s.repositoryTitleTag.Insert(ctx, dbOps, title.ID, tag.ID, title.LibraryID)
+		if err != nil {
+			return fmt.Errorf("sTitle - failed to add tag of title in DB: %w", err)
+		}
 	}
 
 	return nil
 }
 
-func (s *serviceTitle) UpdateTitleWaifu2x(ctx context.Context, dbOps sqlite.DBOps, titleID string, waifu2x int) error {
-	err := s.repositoryTitle.UpdateWaifu2x(ctx, dbOps, titleID, waifu2x)
+func (s *serviceTitle) insertTitleAuthors(ctx context.Context, dbOps sqlite.DBOps, title *model.Title) error {
+	authors, err := s.serviceAuthor.GetOrCreateAuthors(ctx, dbOps, title.Authors)
 	if err != nil {
-		return fmt.Errorf("sTitle - failed to update title's waifu2x flag with given title ID in DB: %w", err)
+		return fmt.Errorf("sTitle - failed to use service Author to get authors of title: %w", err)
+	}
+	for _, author := range authors {
+		err = s.repositoryTitleAuthor.Insert(ctx, dbOps, title.ID, author.ID, title.LibraryID)
+		if err != nil {
+			return fmt.Errorf("sTitle - failed to add author of title in DB: %w", err)
+		}
 	}
 
 	return nil
 }
 
+func (s *serviceTitle) insertTitleCircles(ctx context.Context, dbOps sqlite.DBOps, title *model.Title) error {
+	circles, err := s.serviceAuthor.GetOrCreateCircles(ctx, dbOps, title.Circles)
+	if err != nil {
+		return fmt.Errorf("sTitle - failed to use service Author to get circles of title: %w", err)
+	}
+	for _, circle := range circles {
+		err = s.repositoryTitleCircle.Insert(ctx, dbOps, title.ID, circle.ID, title.LibraryID)
+		if err != nil {
+			return fmt.Errorf("sTitle - failed to add circle of title in DB: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// fillTitlesNames fills in the tags, authors and circles of titles
+func (s *serviceTitle) fillTitlesNames(ctx context.Context, dbOps sqlite.DBOps, titles []*model.Title) error {
+	titleIDs := []int64{}
+	titleByID := make(map[int64]*model.Title)
+	for _, title := range titles {
+		title.Tags = []string{}
+		title.Authors = []string{}
+		title.Circles = []string{}
+		titleIDs = append(titleIDs, title.ID)
+		titleByID[title.ID] = title
+	}
+
+	titleTags, err := s.repositoryTitleTag.FindAllByTitleIDs(ctx, dbOps, titleIDs)
+	if err != nil {
+		return fmt.Errorf("sTitle - failed to find tags of titles in DB: %w", err)
+	}
+	for _, titleTag := range titleTags {
+		title := titleByID[titleTag.TitleID]
+		title.Tags = append(title.Tags, titleTag.Name)
+	}
+
+	titleAuthors, err := s.repositoryTitleAuthor.FindAllByTitleIDs(ctx, dbOps, titleIDs)
+	if err != nil {
+		return fmt.Errorf("sTitle - failed to find authors of titles in DB: %w", err)
+	}
+	for _, titleAuthor := range titleAuthors {
+		title := titleByID[titleAuthor.TitleID]
+		title.Authors = append(title.Authors, titleAuthor.Name)
+	}
+
+	titleCircles, err := s.repositoryTitleCircle.FindAllByTitleIDs(ctx, dbOps, titleIDs)
+	if err != nil {
+		return fmt.Errorf("sTitle - failed to find circles of titles in DB: %w", err)
+	}
+	for _, titleCircle := range titleCircles {
+		title := titleByID[titleCircle.TitleID]
+		title.Circles = append(title.Circles, titleCircle.Name)
+	}
+
+	return nil
+}
+
+// DeleteTitlesByLibraryID deletes the titles of a library along with their search entries,
+// which are not removed by cascade since the search index has no foreign keys
 func (s *serviceTitle) DeleteTitlesByLibraryID(ctx context.Context, dbOps sqlite.DBOps, libraryID string) error {
 	err := s.repositoryTitle.DeleteAllByLibraryID(ctx, dbOps, libraryID)
 	if err != nil {
 		return fmt.Errorf("sTitle - failed to delete all titles with given library ID in DB: %w", err)
 	}
+	err = s.repositoryTitleSearch.DeleteAllByLibraryID(ctx, dbOps, libraryID)
+	if err != nil {
+		return fmt.Errorf("sTitle - failed to delete search entries of titles with given library ID in DB: %w", err)
+	}
 
 	return nil
 }
 
+// DeleteTitleByID deletes a title along with its search entry,
+// its books, pages, previews, tags, authors and circles are deleted along by cascade
 func (s *serviceTitle) DeleteTitleByID(ctx context.Context, dbOps sqlite.DBOps, titleID string) error {
 	err := s.repositoryTitle.DeleteByID(ctx, dbOps, titleID)
 	if err != nil {
 		return fmt.Errorf("sTitle - failed to delete title by ID in DB: %w", err)
 	}
-	err = s.serviceBook.DeleteBooksByTitleID(ctx, dbOps, titleID)
+	err = s.repositoryTitleSearch.DeleteByTitleID(ctx, dbOps, titleID)
 	if err != nil {
-		return fmt.Errorf("sTitle - failed to use service Book to delete books of deleted title: %w", err)
+		return fmt.Errorf("sTitle - failed to delete search entry of title in DB: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/imouto1994/yume/internal/infra/sqlite"
+	"github.com/imouto1994/yume/internal/model"
+	"github.com/imouto1994/yume/internal/repository"
+	"go.uber.org/zap"
+)
+
+type ServiceUserData interface {
+	ExportUserData(context.Context, sqlite.DB) (*model.UserData, error)
+	ImportUserData(context.Context, sqlite.DB, *model.UserData) (*model.UserDataImport, error)
+}
+
+type serviceUserData struct {
+	repositoryLibrary repository.RepositoryLibrary
+	repositoryTitle   repository.RepositoryTitle
+	repositoryPage    repository.RepositoryPage
+	serviceBook       ServiceBook
+	serviceArchive    ServiceArchive
+}
+
+func NewServiceUserData(rLibrary repository.RepositoryLibrary, rTitle repository.RepositoryTitle, rPage repository.RepositoryPage, sBook ServiceBook, sArchive ServiceArchive) ServiceUserData {
+	return &serviceUserData{
+		repositoryLibrary: rLibrary,
+		repositoryTitle:   rTitle,
+		repositoryPage:    rPage,
+		serviceBook:       sBook,
+		serviceArchive:    sArchive,
+	}
+}
+
+// ExportUserData collects the read times of titles and the favorite pages of books in all libraries,
+// titles and books without any of them are left out.
+// DB is read in a single transaction, so that the export is a consistent snapshot even while libraries are scanned
+func (s *serviceUserData) ExportUserData(ctx context.Context, db sqlite.DB) (*model.UserData, error) {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sUserData - failed to begin SQL transaction for exporting user data: %w", err)
+	}
+	libraries, err := s.repositoryLibrary.FindAll(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("sUserData - failed to find all libraries in DB: %w", err)
+	}
+	titlesByLibraryID := make(map[int64][]*model.Title)
+	for _, library := range libraries {
+		titles, err := s.repositoryTitle.FindAllByLibraryID(ctx, tx, fmt.Sprintf("%d", library.ID))
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("sUserData - failed to find all titles of library in DB: %w", err)
+		}
+		titlesByLibraryID[library.ID] = titles
+	}
+	favoritePages, err := s.repositoryPage.FindAllFavorite(ctx, tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("sUserData - failed to find all favorite pages in DB: %w", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("sUserData - failed to commit SQL transaction for exporting user data: %w", err)
+	}
+
+	favoritePagesByTitleID := make(map[int64][]*model.FavoritePage)
+	for _, favoritePage := range favoritePages {
+		favoritePagesByTitleID[favoritePage.TitleID] = append(favoritePagesByTitleID[favoritePage.TitleID], favoritePage)
+	}
+
+	userData := &model.UserData{
+		Version:    model.UserDataVersion,
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Libraries:  []*model.UserDataLibrary{},
+	}
+	for _, library := range libraries {
+		userDataLibrary := &model.UserDataLibrary{
+			Name:   library.Name,
+			Root:   library.Root,
+			Titles: []*model.UserDataTitle{},
+		}
+		for _, title := range titlesByLibraryID[library.ID] {
+			userDataTitle := s.exportTitle(ctx, library, title, favoritePagesByTitleID[title.ID])
+			if userDataTitle.ReadAt != nil || len(userDataTitle.Books) > 0 {
+				userDataLibrary.Titles = append(userDataLibrary.Titles, userDataTitle)
+			}
+		}
+
+		userData.Libraries = append(userData.Libraries, userDataLibrary)
+	}
+
+	return userData, nil
+}
+
+// exportTitle groups the favorite pages of a title by their books, which are listed one after another
+func (s *serviceUserData) exportTitle(ctx context.Context, library *model.Library, title *model.Title, favoritePages []*model.FavoritePage) *model.UserDataTitle {
+	userDataTitle := &model.UserDataTitle{
+		Path:   getLibraryRelativePath(library, title.URL),
+		ReadAt: title.ReadAt,
+	}
+
+	for start := 0; start < len(favoritePages); {
+		end := start + 1
+		for end < len(favoritePages) && favoritePages[end].BookID == favoritePages[start].BookID {
+			end++
+		}
+
+		bookURL := favoritePages[start].BookURL
+		userDataTitle.Books = append(userDataTitle.Books, &model.UserDataBook{
+			Path:          getLibraryRelativePath(library, bookURL),
+			FavoritePages: s.exportPages(ctx, bookURL, favoritePages[start:end]),
+		})
+		start = end
+	}
+
+	return userDataTitle
+}
+
+// exportPages names pages by their files in the book archive as stored in DB. Only the archives of books
+// scanned before file names were stored are read, pages of archives which cannot be read
+// or changed since the last scan are only exported with their numbers
+func (s *serviceUserData) exportPages(ctx context.Context, bookURL string, favoritePages []*model.FavoritePage) []*model.UserDataPage {
+	var probe *model.BookProbe
+	for _, favoritePage := range favoritePages {
+		if favoritePage.FileName != "" {
+			continue
+		}
+
+		var err error
+		probe, err = s.serviceArchive.ProbeBook(ctx, &model.Book{URL: bookURL}, false, nil)
+		if err != nil {
+			zap.L().Warn("sUserData - failed to use service Archive to probe book, favorite pages are exported without file names", zap.String("url", bookURL), zap.Error(err))
+		}
+		break
+	}
+
+	userDataPages := make([]*model.UserDataPage, len(favoritePages))
+	for i, favoritePage := range favoritePages {
+		userDataPages[i] = &model.UserDataPage{
+			File:   favoritePage.FileName,
+			Number: favoritePage.Number,
+		}
+		if favoritePage.FileName == "" && probe != nil && favoritePage.Number < len(probe.Pages) && probe.Pages[favoritePage.Number].Index == favoritePage.Index {
+			userDataPages[i].File = probe.Pages[favoritePage.Number].Name
+		}
+	}
+
+	return userDataPages
+}
+
+// ImportUserData attaches exported user data to the titles, books and pages currently found at the same paths.
+// Imported state is merged into the current one: favorite pages are added and read times are only moved forward.
+// The sidecars of books with imported favorites are written again, so that the favorites also survive rescans
+func (s *serviceUserData) ImportUserData(ctx context.Context, db sqlite.DB, userData *model.UserData) (*model.UserDataImport, error) {
+	err := validateUserData(userData)
+	if err != nil {
+		return nil, err
+	}
+
+	libraries, err := s.repositoryLibrary.FindAll(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("sUserData - failed to find all libraries in DB: %w", err)
+	}
+
+	// Archives are read to match pages before DB is written, so that writes are not held up by reading files
+	result := &model.UserDataImport{UnmatchedPaths: []string{}}
+	readTimeByTitleID := make(map[int64]string)
+	favoritePagesByBookID := make(map[int64][]int)
+	for _, userDataLibrary := range userData.Libraries {
+		library := matchUserDataLibrary(libraries, userDataLibrary)
+		titleByPath := make(map[string]*model.Title)
+		bookByPath := make(map[string]*model.Book)
+		if library != nil {
+			titleByPath, bookByPath, err = s.getLibraryPaths(ctx, db, library)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, userDataTitle := range userDataLibrary.Titles {
+			title := titleByPath[userDataTitle.Path]
+			if title == nil {
+				result.TitlesUnmatched++
+				result.UnmatchedPaths = append(result.UnmatchedPaths, userDataTitle.Path)
+			} else {
+				result.TitlesMatched++
+				readTime := getUserDataReadTime(userDataTitle)
+				if readTime != "" && (title.ReadAt == nil || *title.ReadAt < readTime) {
+					readTimeByTitleID[title.ID] = readTime
+				}
+			}
+
+			// Books are matched by their own paths, as they may have been moved to another title
+			for _, userDataBook := range userDataTitle.Books {
+				book := bookByPath[userDataBook.Path]
+				if book == nil {
+					result.BooksUnmatched++
+					result.PagesUnmatched += len(userDataBook.FavoritePages)
+					result.UnmatchedPaths = append(result.UnmatchedPaths, userDataBook.Path)
+					continue
+				}
+				result.BooksMatched++
+
+				pageNumbers := s.matchPages(ctx, book, userDataBook, result)
+				favoritePagesByBookID[book.ID] = append(favoritePagesByBookID[book.ID], pageNumbers...)
+			}
+		}
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sUserData - failed to begin SQL transaction for importing user data: %w", err)
+	}
+	for titleID, readTime := range readTimeByTitleID {
+		err = s.repositoryTitle.UpdateReadTime(ctx, tx, fmt.Sprintf("%d", titleID), readTime)
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("sUserData - failed to update read time of title in DB: %w", err)
+		}
+	}
+	for bookID, pageNumbers := range favoritePagesByBookID {
+		for _, pageNumber := range pageNumbers {
+			err = s.serviceBook.UpdateBookPageFavorite(ctx, tx, fmt.Sprintf("%d", bookID), pageNumber, 1)
+			if err != nil {
+				tx.Rollback()
+				return nil, fmt.Errorf("sUserData - failed to use service Book to update favorite of book page: %w", err)
+			}
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("sUserData - failed to commit SQL transaction for importing user data: %w", err)
+	}
+
+	for bookID, pageNumbers := range favoritePagesByBookID {
+		if len(pageNumbers) == 0 {
+			continue
+		}
+		err = s.serviceBook.SaveBookSidecar(ctx, db, fmt.Sprintf("%d", bookID))
+		if err != nil {
+			zap.L().Error("sUserData - failed to use service Book to save book sidecar", zap.Int64("bookID", bookID), zap.Error(err))
+		}
+	}
+
+	return result, nil
+}
+
+// getLibraryPaths maps the titles and books of a library by their paths relative to the library root
+func (s *serviceUserData) getLibraryPaths(ctx context.Context, dbOps sqlite.DBOps, library *model.Library) (map[string]*model.Title, map[string]*model.Book, error) {
+	titles, err := s.repositoryTitle.FindAllByLibraryID(ctx, dbOps, fmt.Sprintf("%d", library.ID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("sUserData - failed to find all titles of library in DB: %w", err)
+	}
+
+	titleByPath := make(map[string]*model.Title)
+	bookByPath := make(map[string]*model.Book)
+	for _, title := range titles {
+		titleByPath[getLibraryRelativePath(library, title.URL)] = title
+
+		books, err := s.serviceBook.GetBooksByTitleID(ctx, dbOps, fmt.Sprintf("%d", title.ID))
+		if err != nil {
+			return nil, nil, fmt.Errorf("sUserData - failed to use service Book to get books of title: %w", err)
+		}
+		for _, book := range books {
+			bookByPath[getLibraryRelativePath(library, book.URL)] = book
+		}
+	}
+
+	return titleByPath, bookByPath, nil
+}
+
+// matchPages finds the current numbers of exported favorite pages by their file names,
+// pages exported without file names keep their numbers
+func (s *serviceUserData) matchPages(ctx context.Context, book *model.Book, userDataBook *model.UserDataBook, result *model.UserDataImport) []int {
+	numberByFile := make(map[string]int)
+	if hasUserDataPageFiles(userDataBook) {
+		probe, err := s.serviceArchive.ProbeBook(ctx, book, false, nil)
+		if err != nil {
+			zap.L().Warn("sUserData - failed to use service Archive to probe book, favorite pages cannot be matched by file names", zap.String("url", book.URL), zap.Error(err))
+		} else {
+			for number, entry := range probe.Pages {
+				numberByFile[entry.Name] = number
+			}
+		}
+	}
+
+	pageNumbers := []int{}
+	for _, userDataPage := range userDataBook.FavoritePages {
+		number, ok := userDataPage.Number, userDataPage.Number >= 0 && userDataPage.Number < book.PageCount
+		if userDataPage.File != "" {
+			number, ok = numberByFile[userDataPage.File]
+		}
+		if !ok {
+			result.PagesUnmatched++
+			result.UnmatchedPaths = append(result.UnmatchedPaths, path.Join(userDataBook.Path, userDataPage.File))
+			continue
+		}
+
+		result.PagesMatched++
+		pageNumbers = append(pageNumbers, number)
+	}
+
+	return pageNumbers
+}
+
+func hasUserDataPageFiles(userDataBook *model.UserDataBook) bool {
+	for _, userDataPage := range userDataBook.FavoritePages {
+		if userDataPage.File != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchUserDataLibrary finds the library with the same root, or with the same name when the root moved
+func matchUserDataLibrary(libraries []*model.Library, userDataLibrary *model.UserDataLibrary) *model.Library {
+	for _, library := range libraries {
+		if library.Root == userDataLibrary.Root {
+			return library
+		}
+	}
+	for _, library := range libraries {
+		if library.Name == userDataLibrary.Name {
+			return library
+		}
+	}
+
+	return nil
+}
+
+func validateUserData(userData *model.UserData) error {
+	if userData.Version < 1 || userData.Version > model.UserDataVersion {
+		return fmt.Errorf("sUserData - %w: unsupported version of user data: %d", model.ErrBadRequest, userData.Version)
+	}
+
+	for _, userDataLibrary := range userData.Libraries {
+		for _, userDataTitle := range userDataLibrary.Titles {
+			if userDataTitle.ReadAt == nil {
+				continue
+			}
+			_, err := time.Parse(time.RFC3339, *userDataTitle.ReadAt)
+			if err != nil {
+				return fmt.Errorf("sUserData - %w: read time of title %s is not in RFC 3339 format", model.ErrBadRequest, userDataTitle.Path)
+			}
+		}
+	}
+
+	return nil
+}
+
+// getUserDataReadTime gives the read time of an imported title in UTC, as read times are compared as strings in DB
+func getUserDataReadTime(userDataTitle *model.UserDataTitle) string {
+	if userDataTitle.ReadAt == nil {
+		return ""
+	}
+	readTime, err := time.Parse(time.RFC3339, *userDataTitle.ReadAt)
+	if err != nil {
+		return ""
+	}
+
+	return readTime.UTC().Format(time.RFC3339)
+}
+
+// getLibraryRelativePath gives the path of a title or book relative to the root of its library,
+// with forward slashes so that exports can be imported on other platforms
+func getLibraryRelativePath(library *model.Library, url string) string {
+	relativePath, err := filepath.Rel(library.Root, url)
+	if err != nil {
+		return filepath.ToSlash(url)
+	}
+
+	return filepath.ToSlash(relativePath)
+}
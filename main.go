@@ -1,9 +1,12 @@
 package main
 
 import (
+	"os"
+
 	"github.com/go-playground/validator"
 	"go.uber.org/zap"
 
+	"github.com/imouto1994/yume/internal/command"
 	"github.com/imouto1994/yume/internal/infra/config"
 	httpProtocol "github.com/imouto1994/yume/internal/infra/http"
 	"github.com/imouto1994/yume/internal/infra/migration"
@@ -27,11 +30,21 @@ func main() {
 		zap.L().Fatal("failed to load configurations", zap.Error(err))
 	}
 
-	// Initialize migration
-	migration.UpLatest()
+	// Run maintenance command instead of the server when one is given
+	if len(os.Args) > 1 {
+		runCommand(cfg, os.Args[1], os.Args[2:])
+		return
+	}
+
+	// Initialize migration, pending migrations are left to the migrate command when they are not applied automatically
+	if *cfg.Database.AutoMigrate {
+		migration.UpLatest(cfg.Database)
+	} else {
+		migration.EnsureLatest(cfg.Database)
+	}
 
 	// Intitialize database client
-	db, err := sqlite.Connect()
+	db, err := sqlite.Connect(cfg.Database)
 	if err != nil {
 		zap.L().Fatal("failed to establish connection to database", zap.Error(err))
 	}
@@ -40,3 +53,21 @@ func main() {
 	router := route.CreateRouter(cfg, db, v)
 	httpProtocol.RunServer(router, cfg)
 }
+
+func runCommand(cfg *config.Config, name string, args []string) {
+	var err error
+	switch name {
+	case "migrate":
+		err = command.RunMigrate(cfg, args)
+	case "orphans":
+		err = command.RunOrphans(cfg, args)
+	case "restore":
+		err = command.RunRestore(cfg, args)
+	default:
+		zap.L().Fatal("unknown command", zap.String("command", name))
+	}
+
+	if err != nil {
+		zap.L().Fatal("failed to run command", zap.String("command", name), zap.Error(err))
+	}
+}